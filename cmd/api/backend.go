@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/gpng/order-bot/cmd/api/config"
+	"github.com/gpng/order-bot/services/clock"
+	"github.com/gpng/order-bot/services/dedup"
+	"github.com/gpng/order-bot/services/scheduler"
+	"go.uber.org/zap"
+)
+
+// jobBackend is where scheduled jobs and processed update ids are kept, redis or postgres
+type jobBackend struct {
+	scheduler scheduler.Scheduler
+	dedup     dedup.Deduplicator
+	// only set for the redis backend
+	redisPool *redis.Pool
+	// only set for the postgres backend
+	postgres *scheduler.Postgres
+}
+
+// openJobBackend selected by JOB_BACKEND
+func openJobBackend(cfg config.Config, db *sql.DB, l *zap.Logger) (*jobBackend, error) {
+	switch cfg.JobBackend {
+	case config.JobBackendRedis:
+		redisPool := &redis.Pool{
+			MaxActive: 5,
+			MaxIdle:   5,
+			Wait:      true,
+			Dial: func() (redis.Conn, error) {
+				return redis.DialURL(cfg.RedisURL, redis.DialPassword(cfg.RedisPassword))
+			},
+		}
+
+		conn := redisPool.Get()
+		_, err := conn.Do("PING")
+		conn.Close()
+		if err != nil {
+			redisPool.Close()
+			return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		log.Println("redis connection successful")
+
+		return &jobBackend{
+			scheduler: scheduler.NewWork(cfg.RedisNamespace, redisPool),
+			dedup:     dedup.NewRedis(redisPool, cfg.RedisNamespace, cfg.UpdateDedupTTL),
+			redisPool: redisPool,
+		}, nil
+	case config.JobBackendPostgres:
+		pgScheduler := scheduler.NewPostgres(db, clock.Real{}, l)
+		return &jobBackend{
+			scheduler: pgScheduler,
+			dedup:     dedup.NewPostgres(db, cfg.UpdateDedupTTL),
+			postgres:  pgScheduler,
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid JOB_BACKEND: %s", cfg.JobBackend)
+}
+
+// Close the redis pool, the postgres backend shares the db which is closed by its owner
+func (b *jobBackend) Close() error {
+	if b.redisPool != nil {
+		return b.redisPool.Close()
+	}
+	return nil
+}
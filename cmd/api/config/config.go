@@ -1,22 +1,51 @@
 package config
 
 import (
+	"time"
+
 	"github.com/caarlos0/env"
 	// auto loads .env
 	_ "github.com/joho/godotenv/autoload"
 )
 
+// update modes
+const (
+	UpdateModeWebhook = "webhook"
+	UpdateModePolling = "polling"
+)
+
+// job backends
+const (
+	JobBackendRedis    = "redis"
+	JobBackendPostgres = "postgres"
+)
+
 // Config for app
 type Config struct {
-	BotToken       string `env:"BOT_TOKEN"`
-	Port           string `env:"PORT" envDefault:"4000"`
-	DbName         string `env:"DB_NAME" envDefault:"order-bot-dev"`
-	DbPassword     string `env:"DB_PASSWORD" envDefault:"postgres"`
-	DbUser         string `env:"DB_USER" envDefault:"postgres"`
-	DbHost         string `env:"DB_HOST" envDefault:"localhost"`
-	RedisURL       string `env:"REDIS_URL" envDefault:"redis://localhost:6379/0"`
-	RedisPassword  string `env:"REDIS_PASSWORD" envDefault:"" json:"-"`
-	RedisNamespace string `env:"REDIS_NAMESPACE" envDefault:"order_bot_dev"`
+	BotToken           string        `env:"BOT_TOKEN"`
+	Port               string        `env:"PORT" envDefault:"4000"`
+	DbName             string        `env:"DB_NAME" envDefault:"order-bot-dev"`
+	DbPassword         string        `env:"DB_PASSWORD" envDefault:"postgres"`
+	DbUser             string        `env:"DB_USER" envDefault:"postgres"`
+	DbHost             string        `env:"DB_HOST" envDefault:"localhost"`
+	MigrateOnStart     bool          `env:"MIGRATE_ON_START" envDefault:"false"`
+	RedisURL           string        `env:"REDIS_URL" envDefault:"redis://localhost:6379/0"`
+	RedisPassword      string        `env:"REDIS_PASSWORD" envDefault:"" json:"-"`
+	RedisNamespace     string        `env:"REDIS_NAMESPACE" envDefault:"order_bot_dev"`
+	JobBackend         string        `env:"JOB_BACKEND" envDefault:"redis"`
+	ReconcileInterval  time.Duration `env:"RECONCILE_INTERVAL" envDefault:"5m"`
+	UpdateMode         string        `env:"UPDATE_MODE" envDefault:"webhook"`
+	UpdateDedupTTL     time.Duration `env:"UPDATE_DEDUP_TTL" envDefault:"24h"`
+	UpdateWorkers      int           `env:"UPDATE_WORKERS" envDefault:"10"`
+	UpdateQueue        int           `env:"UPDATE_QUEUE" envDefault:"100"`
+	WebhookURL         string        `env:"WEBHOOK_URL"`
+	WebhookPath        string        `env:"WEBHOOK_PATH" envDefault:"/webhook"`
+	WebhookSecret      string        `env:"WEBHOOK_SECRET" json:"-"`
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+	Timezone           string        `env:"TIMEZONE" envDefault:"Asia/Singapore"`
+	OtlpEndpoint       string        `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ReadyTimeout       time.Duration `env:"READY_TIMEOUT" envDefault:"2s"`
+	ReadyCheckTelegram bool          `env:"READY_CHECK_TELEGRAM" envDefault:"false"`
 }
 
 // New app config
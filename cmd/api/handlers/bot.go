@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	tgbotapi "github.com/dilfish/telegram-bot-api-up"
+	"github.com/gpng/order-bot/services/telegram"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+// sendMessage sends text to the chat, see handleBotError for the returned error
+func (h *Handlers) sendMessage(ctx context.Context, chatID int64, formatHTML bool, text string) error {
+	_, err := h.Bot.SendMessage(ctx, chatID, formatHTML, text)
+	return h.handleBotError(ctx, chatID, err)
+}
+
+// sendInlineKeyboardMessage sends text with the keyboard to the chat, see handleBotError for the returned error
+func (h *Handlers) sendInlineKeyboardMessage(ctx context.Context, chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	_, err := h.Bot.SendInlineKeyboardMessage(ctx, chatID, text, keyboard)
+	return h.handleBotError(ctx, chatID, err)
+}
+
+// editMessage replaces the text of a sent message, see handleBotError for the returned error
+func (h *Handlers) editMessage(ctx context.Context, chatID int64, messageID int, text string) error {
+	err := h.Bot.EditMessage(ctx, chatID, messageID, text)
+	return h.handleBotError(ctx, chatID, err)
+}
+
+// handleBotError reacts to telegram errors, returning nil for errors that have been handled
+func (h *Handlers) handleBotError(ctx context.Context, chatID int64, err error) error {
+	if err == nil {
+		return nil
+	}
+	l := h.logger(ctx)
+
+	var migrated *telegram.ChatMigratedError
+	switch {
+	case errors.Is(err, telegram.ErrMessageNotModified):
+		return nil
+	case errors.Is(err, telegram.ErrBotKicked), errors.Is(err, telegram.ErrBotBlocked), errors.Is(err, telegram.ErrChatNotFound):
+		l.Info("unable to message chat, closing active order", zap.Error(err))
+		h.closeUnreachableChat(ctx, chatID)
+		return nil
+	case errors.As(err, &migrated):
+		l.Warn("chat migrated to supergroup", zap.Int64("migrate_to_chat_id", migrated.MigrateToChatID))
+		h.migrateChat(ctx, chatID, migrated.MigrateToChatID)
+		return nil
+	}
+
+	l.Error("failed to send message", zap.Error(err))
+	return err
+}
+
+// closeUnreachableChat deactivates the active order of a chat the bot can no longer message
+func (h *Handlers) closeUnreachableChat(ctx context.Context, chatID int64) {
+	order, err := h.closeActiveOrder(ctx, chatID, models.OrderEventClose, 0, 0)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			h.logger(ctx).Error("error cancelling active orders", zap.Int64("closed_chat_id", chatID), zap.Error(err))
+		}
+		return
+	}
+	h.deleteOrderJobs(ctx, order)
+}
+
+// migrateChat moves the orders, api keys and language of a group upgraded to a supergroup to its new chat id.
+// Jobs refer to orders by id, so they follow the orders.
+func (h *Handlers) migrateChat(ctx context.Context, oldChatID int64, chatID int64) {
+	l := h.logger(ctx).With(zap.Int64("migrate_from_chat_id", oldChatID), zap.Int64("migrate_to_chat_id", chatID))
+
+	err := h.Repo.MigrateChatID(ctx, models.MigrateChatIDParams{
+		ChatID:    chatID,
+		OldChatID: oldChatID,
+	})
+	if err != nil {
+		l.Error("error migrating chat", zap.Error(err))
+		return
+	}
+
+	// left behind when the supergroup already has an active order, and can no longer be messaged
+	h.closeUnreachableChat(ctx, oldChatID)
+}
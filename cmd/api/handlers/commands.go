@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/dilfish/telegram-bot-api-up"
+	"github.com/gpng/order-bot/services/command"
+	"github.com/gpng/order-bot/services/telegram"
+)
+
+// maxEventLogLimit is the most events /log limit= can show
+const maxEventLogLimit = 100
+
+var (
+	isExpiry  = regexp.MustCompile("^(2[0-3]|[01]?[0-9]):([0-5]?[0-9])$").MatchString
+	isInteger = regexp.MustCompile("^-?[0-9]+$").MatchString
+)
+
+// isQuantity is a non-zero integer, so that /order 0 teh orders 1 "0 teh" as before quantities were parsed.
+// Integers too large for an int are quantities, to be rejected as invalid.
+func isQuantity(value string) bool {
+	n, err := strconv.Atoi(value)
+	return isInteger(value) && (n != 0 || err != nil)
+}
+
+// isLogLimit between 1 and maxEventLogLimit
+func isLogLimit(value string) bool {
+	n, err := strconv.Atoi(value)
+	return err == nil && n > 0 && n <= maxEventLogLimit
+}
+
+// oneOf values, ignoring case
+func oneOf(values ...string) func(string) bool {
+	return func(value string) bool {
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// commands typed by users, in the order shown by /help. Descriptions are message keys.
+var commands = command.NewSet(
+	command.Spec{
+		Name:        "start",
+		Description: MsgHelpStart,
+	},
+	command.Spec{
+		Name:        "help",
+		Description: MsgHelpHelp,
+	},
+	command.Spec{
+		Name:        "takeorders",
+		Aliases:     []string{"takeorder", "neworder", "neworders"},
+		Description: MsgHelpTakeOrders,
+		Args: []command.Arg{
+			// either can be left out but not both, see handleTakeOrder
+			{Name: "time", Optional: true, Match: isExpiry},
+			{Name: "title", Optional: true, Rest: true},
+		},
+	},
+	command.Spec{
+		Name:        "order",
+		Description: MsgHelpOrder,
+		Args: []command.Arg{
+			{Name: "quantity", Optional: true, Match: isQuantity},
+			{Name: "item", Rest: true},
+		},
+	},
+	command.Spec{
+		Name:        "checkorder",
+		Aliases:     []string{"checkorders"},
+		Description: MsgHelpCheckOrder,
+	},
+	command.Spec{
+		Name:        "cancelorder",
+		Aliases:     []string{"removeorder"},
+		Description: MsgHelpCancelOrder,
+	},
+	command.Spec{
+		Name:        "endorders",
+		Aliases:     []string{"endorder", "endtakeorders", "endtakeorder"},
+		Description: MsgHelpEndOrders,
+	},
+	command.Spec{
+		Name:        "log",
+		Description: MsgHelpLog,
+		Options: []command.Option{
+			{Name: "limit", Match: isLogLimit},
+		},
+	},
+	command.Spec{
+		Name:        "language",
+		Aliases:     []string{"lang"},
+		Description: MsgHelpLanguage,
+		Args: []command.Arg{
+			{Name: "language", Optional: true},
+		},
+	},
+	command.Spec{
+		Name:        "apikey",
+		Aliases:     []string{"apikeys"},
+		Description: MsgHelpAPIKey,
+		Args: []command.Arg{
+			{Name: "action", Match: oneOf("create", "list", "revoke")},
+			{Name: "id", Optional: true, Match: isInteger},
+		},
+	},
+)
+
+// callbacks sent by the inline keyboard buttons of the bot's messages
+var callbacks = command.NewSet(
+	command.Spec{
+		Name: "delete",
+		Args: []command.Arg{
+			{Name: "id", Match: isInteger},
+		},
+	},
+	command.Spec{
+		Name: "cancel",
+	},
+)
+
+func (h *Handlers) handleHelp(ctx context.Context, chatID int64) {
+	t := h.t(ctx)
+	lines := []string{t.T(MsgHelp), ""}
+	for _, spec := range commands.Specs() {
+		lines = append(lines, fmt.Sprintf("%s - %s", spec.Usage(), t.T(spec.Description)))
+	}
+	h.sendMessage(ctx, chatID, false, strings.Join(lines, "\n"))
+}
+
+// commandErrorMessage explains how the command was invalid, with its usage
+func (h *Handlers) commandErrorMessage(ctx context.Context, err *command.Error) string {
+	t := h.t(ctx)
+	usage := err.Spec.Usage()
+	switch err.Kind {
+	case command.ErrMissingArg:
+		return t.T(MsgCommandMissingArg, err.Name, usage)
+	case command.ErrInvalidArg:
+		return t.T(MsgCommandInvalidArg, err.Name, err.Value, usage)
+	case command.ErrInvalidOption:
+		return t.T(MsgCommandInvalidOption, err.Name, err.Value, usage)
+	case command.ErrUnterminatedQuote:
+		return t.T(MsgCommandUnterminatedQuote, usage)
+	}
+	return t.T(MsgError)
+}
+
+// RegisterCommands with telegram, so that clients suggest them in the language of the user
+func (h *Handlers) RegisterCommands(ctx context.Context, bot *telegram.Bot) error {
+	for i, language := range Messages.Languages() {
+		t := Messages.Translator(language)
+		botCommands := make([]tgbotapi.BotCommand, len(commands.Specs()))
+		for j, spec := range commands.Specs() {
+			botCommands[j] = tgbotapi.BotCommand{Command: spec.Name, Description: t.T(spec.Description)}
+		}
+
+		// the fallback language is the default for users of other languages
+		languageCode := language
+		if i == 0 {
+			languageCode = ""
+		}
+		if err := bot.SetCommands(ctx, botCommands, languageCode); err != nil {
+			return fmt.Errorf("failed to set %s commands: %w", language, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/gpng/order-bot/services/command"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+// eventLogLimit is the number of events shown by /log, unless limit= is given
+const eventLogLimit = 20
+
+// newOrderEvent of the order, userID and updateID are left null when 0
+func (h *Handlers) newOrderEvent(orderID int32, eventType string, userID int64, updateID int) models.CreateOrderEventParams {
+	return models.CreateOrderEventParams{
+		OrderID:   orderID,
+		Type:      eventType,
+		UserID:    sql.NullInt64{Int64: userID, Valid: userID != 0},
+		UpdateID:  sql.NullInt64{Int64: int64(updateID), Valid: updateID != 0},
+		CreatedAt: h.now(),
+	}
+}
+
+// closeActiveOrder deactivates the active order of the chat and records the event in one transaction
+func (h *Handlers) closeActiveOrder(ctx context.Context, chatID int64, eventType string, userID int64, updateID int) (models.Order, error) {
+	var order models.Order
+	err := h.Repo.ExecTx(ctx, func(q models.Querier) error {
+		var err error
+		order, err = q.CancelOrder(ctx, chatID)
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateOrderEvent(ctx, h.newOrderEvent(order.ID, eventType, userID, updateID))
+		return err
+	})
+	return order, err
+}
+
+// closeOrder deactivates the order if it is still active and records the event in one transaction,
+// returning sql.ErrNoRows if it was already closed
+func (h *Handlers) closeOrder(ctx context.Context, orderID int32, eventType string, userID int64, updateID int) (models.Order, error) {
+	var order models.Order
+	err := h.Repo.ExecTx(ctx, func(q models.Querier) error {
+		var err error
+		order, err = q.CloseOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateOrderEvent(ctx, h.newOrderEvent(order.ID, eventType, userID, updateID))
+		return err
+	})
+	return order, err
+}
+
+func (h *Handlers) handleLog(ctx context.Context, chatID int64, cmd command.Command) error {
+	l := h.logger(ctx)
+
+	order, err := h.Repo.GetActiveOrder(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoActiveOrders))
+			return nil
+		}
+		l.Error("error fetching active orders", zap.Error(err))
+		return err
+	}
+
+	limit := eventLogLimit
+	if cmd.Option("limit") != "" {
+		// matched by isLogLimit
+		limit, _ = strconv.Atoi(cmd.Option("limit"))
+	}
+
+	events, err := h.Repo.GetRecentOrderEvents(ctx, models.GetRecentOrderEventsParams{
+		OrderID: order.ID,
+		Limit:   int32(limit),
+	})
+	if err != nil {
+		l.Error("error fetching order events", zap.Error(err))
+		return err
+	}
+	if len(events) == 0 {
+		h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoOrderEvents))
+		return nil
+	}
+
+	// newest first from the query, shown oldest first
+	lines := make([]string, len(events))
+	for i, event := range events {
+		lines[len(events)-1-i] = h.formatOrderEvent(ctx, event)
+	}
+
+	message := fmt.Sprintf(`<b>%s</b>
+%s
+`, order.Title, strings.Join(lines, "\n"))
+
+	return h.sendMessage(ctx, chatID, true, message)
+}
+
+// formatOrderEvent as a line of /log, in html
+func (h *Handlers) formatOrderEvent(ctx context.Context, event models.GetRecentOrderEventsRow) string {
+	at := h.timestamp(event.CreatedAt).Format("15:04")
+	name := html.EscapeString(strings.ToLower(event.ItemName))
+
+	t := h.t(ctx)
+	who := t.T(MsgBot)
+	if event.UserID.Valid {
+		who = html.EscapeString(displayName(event.UserID.Int64, event.FirstName.String, event.LastName.String, event.Username.String))
+	}
+
+	switch event.Type {
+	case models.OrderEventCreate:
+		return t.T(MsgEventCreate, at, who)
+	case models.OrderEventAddItem:
+		return t.T(MsgEventAddItem, at, who, event.Quantity, name)
+	case models.OrderEventChangeQuantity:
+		return t.T(MsgEventChangeQuantity, at, who, event.Quantity, name)
+	case models.OrderEventDeleteItem:
+		return t.T(MsgEventDeleteItem, at, who, event.Quantity, name)
+	case models.OrderEventClose:
+		return t.T(MsgEventClose, at, who)
+	case models.OrderEventExpire:
+		return t.T(MsgEventExpire, at)
+	}
+	return fmt.Sprintf("%s %s %s", at, who, html.EscapeString(event.Type))
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gpng/order-bot/services/command"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyBytes  = 32
+	apiKeyPrefix = "ob_"
+)
+
+func (h *Handlers) handleAPIKey(ctx context.Context, chat models.Chat, cmd command.Command, user models.User) error {
+	l := h.logger(ctx)
+
+	isAdmin, err := h.isChatAdmin(ctx, chat, user)
+	if err != nil {
+		l.Error("error checking chat admin", zap.Error(err))
+		return err
+	}
+	if !isAdmin {
+		h.sendMessage(ctx, chat.ID, false, h.t(ctx).T(MsgAPIKeyAdminOnly))
+		return nil
+	}
+
+	switch strings.ToLower(cmd.Arg("action")) {
+	case "create":
+		return h.createAPIKey(ctx, chat, user)
+	case "list":
+		return h.listAPIKeys(ctx, chat.ID)
+	case "revoke":
+		id, err := strconv.Atoi(cmd.Arg("id"))
+		if err != nil {
+			h.sendMessage(ctx, chat.ID, false, h.t(ctx).T(MsgAPIKeyInvalidFormat))
+			return nil
+		}
+		return h.revokeAPIKey(ctx, chat.ID, int32(id))
+	}
+
+	h.sendMessage(ctx, chat.ID, false, h.t(ctx).T(MsgAPIKeyInvalidFormat))
+	return nil
+}
+
+// isChatAdmin returns true for private chats, as the user is the only member
+func (h *Handlers) isChatAdmin(ctx context.Context, chat models.Chat, user models.User) (bool, error) {
+	if chat.Type == "private" {
+		return true, nil
+	}
+	return h.Bot.IsChatAdmin(ctx, chat.ID, user.ID)
+}
+
+func (h *Handlers) createAPIKey(ctx context.Context, chat models.Chat, user models.User) error {
+	l := h.logger(ctx)
+
+	key, err := generateAPIKey()
+	if err != nil {
+		l.Error("error generating api key", zap.Error(err))
+		return err
+	}
+
+	apiKey, err := h.Repo.CreateAPIKey(ctx, models.CreateAPIKeyParams{
+		ChatID:    chat.ID,
+		CreatedBy: user.ID,
+		KeyHash:   hashAPIKey(key),
+	})
+	if err != nil {
+		l.Error("error creating api key", zap.Error(err))
+		return err
+	}
+
+	// keys are only ever sent privately so that non admins in groups cannot see them
+	_, err = h.Bot.SendMessage(ctx, user.ID, false, h.t(ctx).T(MsgAPIKeyCreated, apiKey.ID, key))
+	if err != nil {
+		l.Warn("error sending api key privately", zap.Error(err))
+		_, err = h.Repo.RevokeAPIKey(ctx, models.RevokeAPIKeyParams{
+			ID:     apiKey.ID,
+			ChatID: chat.ID,
+		})
+		if err != nil {
+			l.Error("error revoking unsent api key", zap.Error(err))
+			return err
+		}
+		h.sendMessage(ctx, chat.ID, false, h.t(ctx).T(MsgAPIKeyStartPrivateChat))
+		return nil
+	}
+
+	if chat.Type != "private" {
+		h.sendMessage(ctx, chat.ID, false, h.t(ctx).T(MsgAPIKeySent))
+	}
+
+	return nil
+}
+
+func (h *Handlers) listAPIKeys(ctx context.Context, chatID int64) error {
+	l := h.logger(ctx)
+
+	apiKeys, err := h.Repo.GetAPIKeysByChatID(ctx, chatID)
+	if err != nil {
+		l.Error("error fetching api keys", zap.Error(err))
+		return err
+	}
+	if len(apiKeys) == 0 {
+		h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoAPIKeys))
+		return nil
+	}
+
+	t := h.t(ctx)
+	message := "<b>" + t.T(MsgAPIKeys) + "</b>\n"
+	for _, apiKey := range apiKeys {
+		message += t.T(MsgAPIKeyListItem,
+			apiKey.ID,
+			h.timestamp(apiKey.CreatedAt).Format("2 Jan 2006 15:04"),
+			fmt.Sprintf("<a href=\"tg://user?id=%d\">%d</a>", apiKey.CreatedBy, apiKey.CreatedBy),
+		) + "\n"
+	}
+	message += "\n" + t.T(MsgAPIKey)
+
+	h.sendMessage(ctx, chatID, true, message)
+
+	return nil
+}
+
+func (h *Handlers) revokeAPIKey(ctx context.Context, chatID int64, id int32) error {
+	l := h.logger(ctx)
+
+	apiKey, err := h.Repo.RevokeAPIKey(ctx, models.RevokeAPIKeyParams{
+		ID:     id,
+		ChatID: chatID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgAPIKeyNotFound))
+			return nil
+		}
+		l.Error("error revoking api key", zap.Error(err))
+		return err
+	}
+
+	h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgAPIKeyRevoked, apiKey.ID))
+
+	return nil
+}
+
+// generateAPIKey returns a random key, only its hash is stored
+func generateAPIKey() (string, error) {
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
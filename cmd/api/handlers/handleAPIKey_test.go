@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gpng/order-bot/cmd/api/handlers"
+	"github.com/gpng/order-bot/cmd/api/handlers/handlerstest"
+)
+
+func TestAPIKeyCreateAndRevoke(t *testing.T) {
+	h := handlerstest.New(t)
+	h.Telegram.SetAdmin(group.ID, alice.ID)
+
+	h.SendText(group, alice, "/apikey create")
+	h.ExpectLastSent(group.ID, en(handlers.MsgAPIKeySent))
+	// keys are only sent privately
+	created := h.ExpectLastSent(alice.ID, "API key 1 created")
+	key := created.Text[strings.LastIndex(created.Text, "\n")+1:]
+	if !strings.HasPrefix(key, "ob_") {
+		t.Fatalf("expected a key in the private message, got:\n%s", created.Text)
+	}
+	for _, m := range h.Telegram.Sent(group.ID) {
+		if strings.Contains(m.Text, key) {
+			t.Fatalf("expected the key not to be sent to the group, got:\n%s", m.Text)
+		}
+	}
+
+	h.SendText(group, alice, "/apikey list")
+	h.ExpectLastSent(group.ID, "1 - created")
+
+	// there are no api routes yet, so an authenticated request is not found rather than unauthorized
+	if rec := h.GetWithAPIKey("/api/orders", key); rec.Code != http.StatusNotFound {
+		t.Fatalf("responded with %d to a valid api key, want 404", rec.Code)
+	}
+	if rec := h.Get("/api/orders"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("responded with %d without an api key, want 401", rec.Code)
+	}
+
+	h.SendText(group, alice, "/apikey revoke 1")
+	h.ExpectLastSent(group.ID, en(handlers.MsgAPIKeyRevoked, 1))
+	h.SendText(group, alice, "/apikey revoke 1")
+	h.ExpectLastSent(group.ID, en(handlers.MsgAPIKeyNotFound))
+	h.SendText(group, alice, "/apikey list")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNoAPIKeys))
+	if rec := h.GetWithAPIKey("/api/orders", key); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("responded with %d to a revoked api key, want 401", rec.Code)
+	}
+}
+
+func TestAPIKeyAdminOnly(t *testing.T) {
+	h := handlerstest.New(t)
+	h.Telegram.SetAdmin(group.ID, alice.ID)
+
+	h.SendText(group, bob, "/apikey create")
+	h.ExpectLastSent(group.ID, en(handlers.MsgAPIKeyAdminOnly))
+	if sent := h.Telegram.Sent(bob.ID); len(sent) != 0 {
+		t.Fatalf("expected no key to be sent to a non admin, got %+v", sent)
+	}
+	apiKeys, err := h.Repo.GetAPIKeysByChatID(context.Background(), group.ID)
+	if err != nil {
+		t.Fatalf("GetAPIKeysByChatID: %v", err)
+	}
+	if len(apiKeys) != 0 {
+		t.Fatalf("expected no api keys, got %+v", apiKeys)
+	}
+
+	h.SendText(group, bob, "/apikey revoke 1")
+	h.ExpectLastSent(group.ID, en(handlers.MsgAPIKeyAdminOnly))
+}
@@ -2,18 +2,43 @@ package handlers
 
 import (
 	"net/http"
+
+	"github.com/gpng/order-bot/services/version"
+	"go.uber.org/zap"
 )
 
 type statusResponse struct {
-	Version int `json:"version"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
 }
 
-// handleStatus returns the current api version
+// handleStatus returns the build version and commit
 func (h *Handlers) handleStatus() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		status := statusResponse{
-			Version: 1,
+			Version: version.Version,
+			Commit:  version.Commit,
 		}
 		respond(w, dataMessage(status, "API responding"))
 	}
 }
+
+// handleHealthz reports the process is alive, without checking its dependencies
+func (h *Handlers) handleHealthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respond(w, message("OK"))
+	}
+}
+
+// handleReadyz runs the readiness checks, responding 503 with each check's status if any failed
+func (h *Handlers) handleReadyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := h.Health.Run(r.Context())
+		if !report.OK() {
+			h.logger(r.Context()).Warn("not ready", zap.Any("checks", report.Checks))
+			respondWithStatus(w, http.StatusServiceUnavailable, dataMessage(report, "Not ready"))
+			return
+		}
+		respond(w, dataMessage(report, "Ready"))
+	}
+}
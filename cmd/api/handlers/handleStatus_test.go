@@ -0,0 +1,62 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gpng/order-bot/cmd/api/handlers/handlerstest"
+	"github.com/gpng/order-bot/services/health"
+	"github.com/gpng/order-bot/services/version"
+)
+
+func TestStatusReportsVersion(t *testing.T) {
+	h := handlerstest.New(t)
+
+	var body struct {
+		Data struct {
+			Version string `json:"version"`
+			Commit  string `json:"commit"`
+		} `json:"data"`
+	}
+	rec := h.Get("/")
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if body.Data.Version != version.Version || body.Data.Commit != version.Commit {
+		t.Fatalf("status = %+v, want version %s and commit %s", body.Data, version.Version, version.Commit)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	h := handlerstest.New(t)
+
+	if rec := h.Get("/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("healthz responded with %d", rec.Code)
+	}
+	if rec := h.Get("/readyz"); rec.Code != http.StatusOK {
+		t.Fatalf("readyz responded with %d: %s", rec.Code, rec.Body)
+	}
+
+	h.Handlers.Health.Add("postgres", func(ctx context.Context) error { return errors.New("connection refused") })
+	rec := h.Get("/readyz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz with a failing check responded with %d", rec.Code)
+	}
+	var body struct {
+		Data health.Report `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode readiness: %v", err)
+	}
+	if body.Data.Checks["postgres"].Error != "connection refused" || body.Data.Checks["telegram"].Status != health.StatusOK {
+		t.Fatalf("readiness = %+v, want postgres failing and telegram ok", body.Data)
+	}
+
+	// liveness doesn't depend on the checks
+	if rec := h.Get("/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("healthz responded with %d", rec.Code)
+	}
+}
@@ -7,192 +7,333 @@ import (
 	"errors"
 	"fmt"
 	"html"
+	"math"
 	"net/http"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/dilfish/telegram-bot-api-up"
-	"github.com/gocraft/work"
+	"github.com/gpng/order-bot/services/command"
+	"github.com/gpng/order-bot/services/logger"
+	"github.com/gpng/order-bot/services/metrics"
+	"github.com/gpng/order-bot/services/scheduler"
 	"github.com/gpng/order-bot/sqlc/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+const updateSubmitTimeout = 10 * time.Second
+
 func (h *Handlers) handleUpdates() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		status := http.StatusOK
+		defer func() {
+			metrics.WebhookDuration.WithLabelValues(strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		}()
+
 		update := &models.TelegramUpdate{}
 
 		if err := json.NewDecoder(r.Body).Decode(update); err != nil {
-			h.Logger.Error("failed to decoding body", zap.Error(err))
+			h.logger(r.Context()).Error("failed to decoding body", zap.Error(err))
 			return
 		}
 
-		if update.CallbackQuery != nil {
-			var err error
-			switch strings.ToLower(strings.Split(update.CallbackQuery.Data, " ")[0]) {
-			case "/delete":
-				err = h.handleDeleteItem(*update.CallbackQuery)
-				break
-			case "/cancel":
-				err = h.handleCancelDeleteOrder(*update.CallbackQuery)
-				break
-			}
-			h.Bot.BotAPI.AnswerCallbackQuery(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
-			if err != nil {
+		// dispatched after the response is sent, so only the logger is kept from the request context
+		updateCtx := h.updateContext(logger.WithContext(context.Background(), h.logger(r.Context())), update)
 
-				return
-			}
+		// respond straight away, slow telegram calls would otherwise cause redeliveries
+		ctx, cancel := context.WithTimeout(r.Context(), updateSubmitTimeout)
+		defer cancel()
+		err := h.Updates.Submit(ctx, updateChatID(update), func() {
+			h.dispatchUpdate(updateCtx, update)
+		})
+		if err != nil {
+			h.logger(updateCtx).Error("failed to queue update", zap.Error(err))
+			status = http.StatusServiceUnavailable
+			respondWithStatus(w, http.StatusServiceUnavailable, errorMessage(http.StatusServiceUnavailable, h.t(r.Context()).T(MsgError)))
 			return
 		}
+	}
+}
 
-		if update.Message != nil {
-			if update.Message.GroupChatCreated {
-				h.handleStart(update.Message.Chat.ID)
-			}
-			if len(update.Message.NewChatMembers) > 0 {
-				h.handleNewChatMembers(update.Message.Chat.ID, update.Message.NewChatMembers)
-				return
-			}
+// updateChatID is the chat an update belongs to, updates of the same chat are processed in order
+func updateChatID(update *models.TelegramUpdate) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	}
+	return 0
+}
 
-			chatID := update.Message.Chat.ID
-			text := update.Message.Text
-			split := strings.Split(text, " ")
-
-			var err error
-			switch strings.ToLower(split[0]) {
-			case "/start", "/help":
-				h.handleStart(chatID)
-				return
-			case "/takeorders", "/takeorder", "/neworder", "/neworders":
-				err = h.handleTakeOrder(chatID, text)
-				break
-			case "/endorders", "/endorder", "/endtakeorders", "/endtakeorder":
-				err = h.handleEndOrder(chatID)
-				break
-			case "/order":
-				err = h.handlerOrder(chatID, text, update.Message.From)
-				break
-			case "/cancelorder", "/removeorder":
-				err = h.handleCancelOrder(chatID, update.Message.From)
-				break
-			case "/checkorder", "/checkorders":
-				err = h.handlerCheckOrder(chatID)
-				break
-			}
+// updateContext carries a logger with the ids and command of the update, for the handlers it is dispatched to
+func (h *Handlers) updateContext(ctx context.Context, update *models.TelegramUpdate) context.Context {
+	fields := []zap.Field{zap.Int("update_id", update.UpdateID)}
+	var cmd command.Command
+	var err error
+	switch {
+	case update.Message != nil:
+		fields = append(fields, zap.Int64("chat_id", update.Message.Chat.ID), zap.Int64("user_id", update.Message.From.ID))
+		cmd, err = commands.Parse(update.Message.Text, h.Bot.Username())
+	case update.CallbackQuery != nil:
+		if update.CallbackQuery.Message != nil {
+			fields = append(fields, zap.Int64("chat_id", update.CallbackQuery.Message.Chat.ID))
+		}
+		fields = append(fields, zap.Int64("user_id", update.CallbackQuery.From.ID))
+		cmd, err = callbacks.Parse(update.CallbackQuery.Data, h.Bot.Username())
+	}
+	// invalid commands are logged too, as they are answered with their usage
+	var invalid *command.Error
+	if errors.As(err, &invalid) {
+		cmd.Name, err = invalid.Spec.Name, nil
+	}
+	if err == nil && cmd.Name != "" {
+		fields = append(fields, zap.String("command", "/"+cmd.Name))
+	}
+	return logger.WithContext(ctx, h.logger(ctx).With(fields...))
+}
 
-			if err != nil {
-				h.Bot.SendMessage(chatID, false, MsgError)
+// dispatchUpdate routes an update to its command handler, shared by the webhook and polling transports.
+// ctx carries the logger of the update, see updateContext.
+func (h *Handlers) dispatchUpdate(ctx context.Context, update *models.TelegramUpdate) {
+	ctx, span := tracer.Start(ctx, "update", trace.WithAttributes(attribute.Int("telegram.update_id", update.UpdateID)))
+	defer span.End()
+	l := h.logger(ctx)
+
+	// telegram redelivers updates when we are slow or error, so commands must only run once
+	seen, err := h.Dedup.Seen(update.UpdateID)
+	if err != nil {
+		l.Error("failed to check duplicate update", zap.Error(err))
+	} else if seen {
+		l.Info("skipping duplicate update")
+		return
+	}
+
+	h.syncProfiles(ctx, update)
+
+	if update.CallbackQuery != nil {
+		if update.CallbackQuery.Message != nil {
+			ctx = h.withLanguage(ctx, update.CallbackQuery.Message.Chat.ID, update.CallbackQuery.From.LanguageCode)
+		}
+
+		var name string
+		cmd, err := callbacks.Parse(update.CallbackQuery.Data, h.Bot.Username())
+		var invalid *command.Error
+		switch {
+		case errors.As(err, &invalid):
+			// buttons are made by the bot, so their data is only invalid after a breaking change
+			l.Error("invalid callback data", zap.String("data", update.CallbackQuery.Data), zap.Error(err))
+			if update.CallbackQuery.Message != nil {
+				h.editMessage(ctx, update.CallbackQuery.Message.Chat.ID, update.CallbackQuery.Message.MessageID, h.t(ctx).T(MsgInvalidItem))
 			}
+			name, err = "/"+invalid.Spec.Name, nil
+		case err != nil:
+			// unknown callbacks are ignored
+			err = nil
+		case cmd.Name == "delete":
+			name = "/delete"
+			err = h.handleDeleteItem(ctx, *update.CallbackQuery, cmd, update.UpdateID)
+		case cmd.Name == "cancel":
+			name = "/cancel"
+			err = h.handleCancelDeleteOrder(ctx, *update.CallbackQuery)
+		}
+		countCommand(name, err)
+		if answerErr := h.Bot.AnswerCallbackQuery(ctx, update.CallbackQuery.ID, ""); answerErr != nil {
+			l.Error("failed to answer callback query", zap.Error(answerErr))
+		}
+		if err != nil {
+
+			return
+		}
+		return
+	}
+
+	if update.Message != nil {
+		ctx = h.withLanguage(ctx, update.Message.Chat.ID, update.Message.From.LanguageCode)
+
+		// telegram sends both when a group is upgraded to a supergroup, whichever arrives first moves the chat
+		if update.Message.MigrateToChatID != 0 {
+			h.migrateChat(ctx, update.Message.Chat.ID, update.Message.MigrateToChatID)
+			return
+		}
+		if update.Message.MigrateFromChatID != 0 {
+			h.migrateChat(ctx, update.Message.MigrateFromChatID, update.Message.Chat.ID)
+			return
+		}
+		if update.Message.GroupChatCreated {
+			h.handleStart(ctx, update.Message.Chat.ID)
+		}
+		if len(update.Message.NewChatMembers) > 0 {
+			h.handleNewChatMembers(ctx, update.Message.Chat.ID, update.Message.NewChatMembers)
+			return
+		}
+
+		chatID := update.Message.Chat.ID
+		cmd, err := commands.Parse(update.Message.Text, h.Bot.Username())
+		var invalid *command.Error
+		if errors.As(err, &invalid) {
+			countCommand("/"+invalid.Spec.Name, nil)
+			h.sendMessage(ctx, chatID, false, h.commandErrorMessage(ctx, invalid))
+			return
+		}
+		// messages that are not commands for the bot are ignored
+		if err != nil {
+			return
+		}
+
+		switch cmd.Name {
+		case "start":
+			h.handleStart(ctx, chatID)
+			break
+		case "help":
+			h.handleHelp(ctx, chatID)
+			break
+		case "takeorders":
+			err = h.handleTakeOrder(ctx, chatID, cmd, update.Message.From, update.UpdateID)
+			break
+		case "endorders":
+			err = h.handleEndOrder(ctx, chatID, update.Message.From, update.UpdateID)
+			break
+		case "order":
+			err = h.handlerOrder(ctx, chatID, cmd, update.Message.From, update.UpdateID)
+			break
+		case "cancelorder":
+			err = h.handleCancelOrder(ctx, chatID, update.Message.From)
+			break
+		case "checkorder":
+			err = h.handlerCheckOrder(ctx, chatID)
+			break
+		case "log":
+			err = h.handleLog(ctx, chatID, cmd)
+			break
+		case "apikey":
+			err = h.handleAPIKey(ctx, update.Message.Chat, cmd, update.Message.From)
+			break
+		case "language":
+			err = h.handleLanguage(ctx, update.Message.Chat, cmd, update.Message.From)
+			break
+		}
+		countCommand("/"+cmd.Name, err)
+
+		if err != nil {
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgError))
 		}
 	}
 }
 
-func (h *Handlers) handleStart(chatID int64) {
-	h.Bot.SendMessage(chatID, false, fmt.Sprintf(`Use HelpMeBuyLehBot to collect group orders!
+// countCommand handled with err, messages and callbacks that are not commands are skipped
+func countCommand(command string, err error) {
+	if command == "" {
+		return
+	}
+	metrics.Commands.WithLabelValues(command, metrics.Outcome(err)).Inc()
+}
 
+func (h *Handlers) handleStart(ctx context.Context, chatID int64) {
+	t := h.t(ctx)
+	h.sendMessage(ctx, chatID, false, fmt.Sprintf(`%s
+
+%s
+%s
 %s
 %s
 %s
-`, MsgTakeOrders, MsgOrder, MsgEndTakeOrders))
+`, t.T(MsgIntro), t.T(MsgTakeOrders), t.T(MsgOrder), t.T(MsgEndTakeOrders), t.T(MsgLog), t.T(MsgLanguage)))
 }
 
-func (h *Handlers) handleEndOrder(chatID int64) error {
-	l := h.Logger.With(zap.Int64("chat_id", chatID), zap.String("command", "/endorders"))
+func (h *Handlers) handleEndOrder(ctx context.Context, chatID int64, user models.User, updateID int) error {
+	l := h.logger(ctx)
 
-	order, err := h.Repo.CancelOrder(context.Background(), int32(chatID))
+	order, err := h.closeActiveOrder(ctx, chatID, models.OrderEventClose, user.ID, updateID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			h.Bot.SendMessage(chatID, false, MsgNoActiveOrders)
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoActiveOrders))
 			return nil
 		}
 		l.Error("error cancelling active orders", zap.Error(err))
 		return err
 	}
 
-	if order.ReminderRunAt.Valid && order.ReminderID.Valid {
-		err = h.WorkClient.DeleteScheduledJob(order.ReminderRunAt.Int64, order.ReminderID.String)
-		if err != nil && !errors.Is(err, work.ErrNotDeleted) {
-			l.Error("error deleting reminder job", zap.Error(err))
-			return err
-		}
-	}
-	if order.ExpiryRunAt.Valid && order.ExpiryID.Valid {
-		err = h.WorkClient.DeleteScheduledJob(order.ExpiryRunAt.Int64, order.ExpiryID.String)
-		if err != nil && !errors.Is(err, work.ErrNotDeleted) {
-			l.Error("error deleting expiry job", zap.Error(err))
-			return err
-		}
+	err = h.deleteOrderJobs(ctx, order)
+	if err != nil {
+		return err
 	}
 
-	err = h.sendOverview(l, order, false)
+	err = h.sendOverview(ctx, order, false)
 	if err != nil {
 		l.Error("error sennding overview", zap.Error(err))
 		return err
 	}
-	h.Bot.SendMessage(chatID, false, MsgCancelTakeOrders)
+	h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgCancelTakeOrders))
 
 	return nil
 }
 
-func (h *Handlers) handleTakeOrder(chatID int64, text string) error {
-	l := h.Logger.With(zap.Int64("chat_id", chatID), zap.String("command", "/takeorders"))
-
-	split := strings.Split(text, " ")
+// deleteOrderJobs cancels the scheduled reminder and expiry jobs of an order
+func (h *Handlers) deleteOrderJobs(ctx context.Context, order models.Order) error {
+	l := h.logger(ctx)
+	if order.ReminderID.Valid {
+		err := h.cancelJob(ctx, scheduler.Handle(order.ReminderID.String))
+		if err != nil {
+			l.Error("error deleting reminder job", zap.Error(err))
+			return err
+		}
+	}
+	if order.ExpiryID.Valid {
+		err := h.cancelJob(ctx, scheduler.Handle(order.ExpiryID.String))
+		if err != nil {
+			l.Error("error deleting expiry job", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
 
-	if len(split) < 2 {
-		h.Bot.SendMessage(chatID, false, MsgNewTakeOrderInvalidFormat)
+// cancelJob ignores handles of another job backend, as those jobs can't be reached after switching backends
+func (h *Handlers) cancelJob(ctx context.Context, handle scheduler.Handle) error {
+	err := h.Scheduler.Cancel(ctx, handle)
+	if errors.Is(err, scheduler.ErrInvalidHandle) {
+		h.logger(ctx).Warn("skipping job of another backend", zap.String("handle", string(handle)))
 		return nil
 	}
+	return err
+}
 
-	expiry := split[1]
-	title := escapeString(strings.Join(split[2:], " "))
-	r, err := regexp.Compile("^(2[0-3]|[01]?[0-9]):([0-5]?[0-9])$")
-	if err != nil {
-		l.Error("error compiling regex", zap.Error(err))
-		return err
+func (h *Handlers) handleTakeOrder(ctx context.Context, chatID int64, cmd command.Command, user models.User, updateID int) error {
+	expiry := cmd.Arg("time")
+	title := escapeString(cmd.Arg("title"))
+	if expiry == "" && title == "" {
+		spec, _ := commands.Spec(cmd.Name)
+		h.sendMessage(ctx, chatID, false, h.commandErrorMessage(ctx, &command.Error{Spec: spec, Kind: command.ErrMissingArg, Name: "title"}))
+		return nil
 	}
-	if !r.MatchString(expiry) {
-		return h.saveTakeOrder(l,
-			chatID,
+	if expiry == "" {
+		return h.saveTakeOrder(ctx, chatID,
+			user,
+			updateID,
 			expiry,
 			sql.NullTime{Valid: false},
 			false,
-			escapeString(strings.Join(split[1:], " ")),
+			title,
 		)
 	}
 
+	// matched by isExpiry
 	expirySplit := strings.Split(expiry, ":")
+	hour, _ := strconv.Atoi(expirySplit[0])
+	min, _ := strconv.Atoi(expirySplit[1])
 
-	hour, err := strconv.Atoi(expirySplit[0])
-	if err != nil {
-		h.Bot.SendMessage(chatID, false, MsgNewTakeOrderInvalidTime)
-		return nil
-	}
+	expiryTime, isTomorrow := nextExpiry(h.now(), hour, min)
 
-	min, err := strconv.Atoi(expirySplit[1])
-	if err != nil {
-		h.Bot.SendMessage(chatID, false, MsgNewTakeOrderInvalidTime)
-		return nil
-	}
-
-	location, err := getLocation()
-	if err != nil {
-		l.Error("error loading time location", zap.Error(err))
-		return nil
-	}
-
-	now := time.Now().In(location)
-
-	expiryTime := time.Date(now.Year(), now.Month(), now.Day(), hour, min, 0, 0, location)
-
-	isTomorrow := expiryTime.Before(now)
-	if isTomorrow {
-		expiryTime = expiryTime.Add(time.Hour * 24)
-	}
-
-	return h.saveTakeOrder(l,
-		chatID,
+	return h.saveTakeOrder(ctx, chatID,
+		user,
+		updateID,
 		expiry,
 		sql.NullTime{
 			Valid: true,
@@ -203,82 +344,62 @@ func (h *Handlers) handleTakeOrder(chatID int64, text string) error {
 	)
 }
 
-func (h *Handlers) saveTakeOrder(l *zap.Logger, chatID int64, expiry string, expiryTime sql.NullTime, isTomorrow bool, title string) error {
-	activeOrder, err := h.Repo.GetActiveOrder(context.Background(), int32(chatID))
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		l.Error("error fetching active orders", zap.Error(err))
-		return err
-	}
-	if err == nil {
-		h.Bot.SendMessage(chatID, false, MsgNewTakeOrderExistingOrder(activeOrder.Title))
-		return nil
-	}
-
-	order, err := h.Repo.CreateOrder(context.Background(), models.CreateOrderParams{
-		ChatID: int32(chatID),
-		Title:  title,
-		Expiry: expiryTime,
-	})
-	if err != nil {
-		l.Error("error creating order", zap.Error(err))
-		return err
-	}
+func (h *Handlers) saveTakeOrder(ctx context.Context, chatID int64, user models.User, updateID int, expiry string, expiryTime sql.NullTime, isTomorrow bool, title string) error {
+	l := h.logger(ctx)
 
-	if expiryTime.Valid {
-		location, err := getLocation()
+	// the order and its job handles are saved together, jobs of a rolled back order skip themselves
+	err := h.Repo.ExecTx(ctx, func(q models.Querier) error {
+		order, err := q.CreateOrder(ctx, models.CreateOrderParams{
+			ChatID:       chatID,
+			Title:        title,
+			Expiry:       expiryTime,
+			LanguageCode: user.LanguageCode,
+		})
 		if err != nil {
-			l.Error("error loading time location", zap.Error(err))
-			return nil
+			return err
+		}
+		_, err = q.CreateOrderEvent(ctx, h.newOrderEvent(order.ID, models.OrderEventCreate, user.ID, updateID))
+		if err != nil {
+			return err
 		}
-		now := time.Now().In(location)
-
-		diff := expiryTime.Time.Sub(now).Seconds()
 
-		if diff > 600 { // only notify 5 minutes before if more than 10 minutes to go
-			scheduledJob, err := h.Queue.EnqueueUniqueIn(string(JobNotifyExpiry), int64(diff-300), work.Q{
-				jobArgOrderID:   int64(order.ID),
-				jobArgPreExpiry: true,
-			})
-			if err != nil {
-				l.Error("error scheduling job", zap.Error(err))
-				return err
-			}
-			err = h.Repo.UpdateReminder(context.Background(), models.UpdateReminderParams{
-				ID:            order.ID,
-				ReminderRunAt: sql.NullInt64{Int64: scheduledJob.RunAt, Valid: true},
-				ReminderID:    sql.NullString{String: scheduledJob.Job.ID, Valid: true},
-			})
+		if !expiryTime.Valid {
+			return nil
+		}
+		if expiryTime.Time.Sub(h.now()) > reminderMinLead {
+			err = h.scheduleOrderJob(ctx, q, order, true, expiryTime.Time.Add(-reminderBefore))
 			if err != nil {
-				l.Error("error updating reminder details", zap.Error(err))
+				l.Error("error scheduling reminder", zap.Error(err))
 				return err
 			}
 		}
-
-		scheduledJob, err := h.Queue.EnqueueUniqueIn(string(JobNotifyExpiry), int64(diff), work.Q{
-			jobArgOrderID:   int64(order.ID),
-			jobArgPreExpiry: false,
-		})
-
+		err = h.scheduleOrderJob(ctx, q, order, false, expiryTime.Time)
 		if err != nil {
-			l.Error("error scheduling job", zap.Error(err))
+			l.Error("error scheduling expiry", zap.Error(err))
 			return err
 		}
-		err = h.Repo.UpdateExpiry(context.Background(), models.UpdateExpiryParams{
-			ID:          order.ID,
-			ExpiryRunAt: sql.NullInt64{Int64: scheduledJob.RunAt, Valid: true},
-			ExpiryID:    sql.NullString{String: scheduledJob.Job.ID, Valid: true},
-		})
+		return nil
+	})
+	if models.IsUniqueViolation(err) {
+		activeOrder, err := h.Repo.GetActiveOrder(ctx, chatID)
 		if err != nil {
-			l.Error("error updating reminder details", zap.Error(err))
+			l.Error("error fetching active orders", zap.Error(err))
 			return err
 		}
+		h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNewTakeOrderExistingOrder, activeOrder.Title))
+		return nil
+	}
+	if err != nil {
+		l.Error("error creating order", zap.Error(err))
+		return err
 	}
 
-	message := "Taking orders for " + title
+	t := h.t(ctx)
+	message := t.T(MsgTakingOrders, title)
 	if expiryTime.Valid {
-		message = message + ", ending at " + expiry
+		message = t.T(MsgTakingOrdersUntil, title, expiry)
 		if isTomorrow {
-			message += " tomorrow"
+			message = t.T(MsgTakingOrdersUntilTomorrow, title, expiry)
 		}
 	}
 
@@ -286,21 +407,21 @@ func (h *Handlers) saveTakeOrder(l *zap.Logger, chatID int64, expiry string, exp
 	
 %s
 %s
-`, message, MsgEndTakeOrders, MsgOrder)
+`, message, t.T(MsgEndTakeOrders), t.T(MsgOrder))
 
-	h.Bot.SendMessage(chatID, false, fullMessage)
+	h.sendMessage(ctx, chatID, false, fullMessage)
 
 	return nil
 }
 
-func (h *Handlers) handlerCheckOrder(chatID int64) error {
-	l := h.Logger.With(zap.Int64("chat_id", chatID), zap.String("command", "/checkorder"))
+func (h *Handlers) handlerCheckOrder(ctx context.Context, chatID int64) error {
+	l := h.logger(ctx)
 
-	order, err := h.Repo.GetActiveOrder(context.Background(), int32(chatID))
+	order, err := h.Repo.GetActiveOrder(ctx, chatID)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			h.Bot.SendMessage(chatID, false, MsgNoActiveOrders)
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoActiveOrders))
 			return nil
 		}
 
@@ -308,111 +429,88 @@ func (h *Handlers) handlerCheckOrder(chatID int64) error {
 		return err
 	}
 
-	return h.sendOverview(l, order, false)
+	return h.sendOverview(ctx, order, false)
 }
 
-func (h *Handlers) handlerOrder(chatID int64, text string, user models.User) error {
-	l := h.Logger.With(zap.Int64("chat_id", chatID), zap.String("command", "/order"))
-
-	order, err := h.Repo.GetActiveOrder(context.Background(), int32(chatID))
+func (h *Handlers) handlerOrder(ctx context.Context, chatID int64, cmd command.Command, user models.User, updateID int) error {
+	l := h.logger(ctx)
 
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			h.Bot.SendMessage(chatID, false, MsgNoActiveOrders)
+	quantity := 1
+	if cmd.Arg("quantity") != "" {
+		var err error
+		quantity, err = strconv.Atoi(cmd.Arg("quantity"))
+		if err != nil || quantity <= 0 || quantity > math.MaxInt32 {
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgOrderInvalidQuantity))
 			return nil
 		}
-
-		l.Error("error fetching active orders", zap.Error(err))
-		return err
 	}
+	name := cmd.Arg("item")
 
-	split := strings.Split(text, " ")
-
-	if len(split) < 2 {
-		h.Bot.SendMessage(chatID, false, MsgOrderInvalidFormat)
-		return nil
-	}
-
-	quantity, _ := strconv.Atoi(split[1])
-
-	var name string
-	if quantity < 0 {
-		h.Bot.SendMessage(chatID, false, MsgOrderInvalidQuantity)
-		return nil
-	} else if quantity > 0 {
-		name = strings.Join(split[2:], " ")
-	} else { // quantity == 0
-		quantity = 1
-		name = strings.Join(split[1:], " ")
-	}
-
-	item, err := h.Repo.GetItem(context.Background(), models.GetItemParams{
-		OrderID: order.ID,
-		UserID:  int32(user.ID),
-		Lower:   name,
-	})
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		l.Error("error checking item", zap.Error(err))
-		return err
-	}
-
-	if err == nil {
-		_, err = h.Repo.UpdateItemQuantity(context.Background(), models.UpdateItemQuantityParams{
-			OrderID:  order.ID,
-			Quantity: int32(quantity + int(item.Quantity)),
-			Lower:    name,
-			UserID:   int32(user.ID),
-		})
+	// the order is locked until the item is added, so it can't be ended in between.
+	// Items are added in one statement, so concurrent orders of the same item are not lost.
+	var order models.Order
+	err := h.Repo.ExecTx(ctx, func(q models.Querier) error {
+		var err error
+		order, err = q.GetActiveOrderForUpdate(ctx, chatID)
 		if err != nil {
-			l.Error("error creating item", zap.Error(err))
 			return err
 		}
-	} else {
-		_, err = h.Repo.CreateItem(context.Background(), models.CreateItemParams{
+
+		item, err := q.AddItemQuantity(ctx, models.AddItemQuantityParams{
 			OrderID:  order.ID,
-			Quantity: int32(quantity),
+			UserID:   user.ID,
 			Name:     name,
-			UserID:   int32(user.ID),
-			UserName: user.FirstName,
+			Quantity: int32(quantity),
 		})
 		if err != nil {
-			l.Error("error creating item", zap.Error(err))
 			return err
 		}
-	}
-	quantity = quantity + int(item.Quantity)
-
-	return h.sendOverview(l, order, false)
-}
 
-func (h *Handlers) sendOverview(l *zap.Logger, order models.Order, isPreExpiry bool) error {
-	items, err := h.Repo.GetItemsByOrderID(context.Background(), order.ID)
+		eventType := models.OrderEventChangeQuantity
+		if item.Quantity == int32(quantity) {
+			eventType = models.OrderEventAddItem
+		}
+		event := h.newOrderEvent(order.ID, eventType, user.ID, updateID)
+		event.ItemID = sql.NullInt32{Int32: item.ID, Valid: true}
+		event.ItemName = item.Name
+		event.Quantity = int32(quantity)
+		_, err = q.CreateOrderEvent(ctx, event)
+		return err
+	})
 	if err != nil {
-		l.Error("error getting order items", zap.Error(err))
+		if errors.Is(err, sql.ErrNoRows) {
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoActiveOrders))
+			return nil
+		}
+		l.Error("error adding item", zap.Error(err))
 		return err
 	}
 
-	location, err := getLocation()
+	return h.sendOverview(ctx, order, false)
+}
+
+func (h *Handlers) sendOverview(ctx context.Context, order models.Order, isPreExpiry bool) error {
+	l := h.logger(ctx)
+	items, err := h.Repo.GetItemsWithUsersByOrderID(ctx, order.ID)
 	if err != nil {
-		l.Error("error loading time location", zap.Error(err))
+		l.Error("error getting order items", zap.Error(err))
 		return err
 	}
 
-	now := time.Now().In(location)
-
+	t := h.t(ctx)
 	title := order.Title
 
-	expiry := "No expiry"
+	expiry := t.T(MsgNoExpiry)
 	if order.Expiry.Valid {
 		expiry = order.Expiry.Time.Format("15:04")
 
-		if order.Expiry.Time.Day() > now.Day() {
-			expiry += " tomorrow"
+		if isAfterToday(h.now(), order.Expiry.Time) {
+			expiry = t.T(MsgExpiryTomorrow, expiry)
 		}
 
 		if isPreExpiry {
-			expiry += " in 5 minutes"
-			title = "REMINDER\n" + title
+			expiry = t.T(MsgExpirySoon, expiry)
+			title = t.T(MsgReminder) + "\n" + title
 		}
 	}
 
@@ -420,7 +518,8 @@ func (h *Handlers) sendOverview(l *zap.Logger, order models.Order, isPreExpiry b
 	itemsText := ""
 	for _, item := range items {
 		name := html.EscapeString(strings.ToLower(item.Name))
-		itemsText += fmt.Sprintf("<a href=\"tg://user?id=%d\">%s</a> %d x %s\n", item.UserID, item.UserName, item.Quantity, name)
+		userName := html.EscapeString(displayName(item.UserID, item.FirstName, item.LastName, item.Username))
+		itemsText += fmt.Sprintf("<a href=\"tg://user?id=%d\">%s</a> %d x %s\n", item.UserID, userName, item.Quantity, name)
 		allItems[name] += int(item.Quantity)
 	}
 
@@ -434,41 +533,35 @@ func (h *Handlers) sendOverview(l *zap.Logger, order models.Order, isPreExpiry b
 %s
 
 %s
-<b>Consolidated</b>
+<b>%s</b>
 %s
 %s
 %s
-`, title, expiry, itemsText, allItemsText, MsgEndTakeOrders, MsgCancelOrder)
+`, title, expiry, itemsText, t.T(MsgConsolidated), allItemsText, t.T(MsgEndTakeOrders), t.T(MsgCancelOrder))
 
-	h.Bot.SendMessage(int64(order.ChatID), true, message)
-
-	return nil
+	return h.sendMessage(ctx, order.ChatID, true, message)
 }
 
-func getLocation() (*time.Location, error) {
-	return time.LoadLocation("Asia/Singapore")
-}
+func (h *Handlers) handleCancelOrder(ctx context.Context, chatID int64, user models.User) error {
+	l := h.logger(ctx)
 
-func (h *Handlers) handleCancelOrder(chatID int64, user models.User) error {
-	l := h.Logger.With(zap.Int64("chat_id", chatID), zap.String("command", "/cancelorder"))
-
-	order, err := h.Repo.GetActiveOrder(context.Background(), int32(chatID))
+	order, err := h.Repo.GetActiveOrder(ctx, chatID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			h.Bot.SendMessage(chatID, false, MsgNoActiveOrders)
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoActiveOrders))
 			return nil
 		}
 		l.Error("failed to retrieve active order", zap.Error(err))
 		return err
 	}
 
-	items, err := h.Repo.GetUserItems(context.Background(), models.GetUserItemsParams{
-		UserID:  int32(user.ID),
+	items, err := h.Repo.GetUserItems(ctx, models.GetUserItemsParams{
+		UserID:  user.ID,
 		OrderID: order.ID,
 	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			h.Bot.SendMessage(chatID, false, MsgNoOrders)
+			h.sendMessage(ctx, chatID, false, h.t(ctx).T(MsgNoOrders))
 			return nil
 		}
 		l.Error("failed to retrieve user items", zap.Error(err))
@@ -482,80 +575,80 @@ func (h *Handlers) handleCancelOrder(chatID int64, user models.User) error {
 		)
 	}
 	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("Cancel", "/cancel"),
+		tgbotapi.NewInlineKeyboardButtonData(h.t(ctx).T(MsgCancel), "/cancel"),
 	))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
 
-	h.Bot.SendInlineKeyboardMessage(chatID, MsgSelectDeleteOrder, keyboard)
+	h.sendInlineKeyboardMessage(ctx, chatID, h.t(ctx).T(MsgSelectDeleteOrder), keyboard)
 
 	return nil
 }
 
-func (h *Handlers) handleDeleteItem(cq models.CallbackQuery) error {
+func (h *Handlers) handleDeleteItem(ctx context.Context, cq models.CallbackQuery, cmd command.Command, updateID int) error {
 	if cq.Message == nil {
 		return nil
 	}
-	l := h.Logger.With(zap.Int64("chat_id", cq.Message.Chat.ID), zap.String("command", "/deleteitem"))
-
-	split := strings.Split(cq.Data, " ")
-	if len(split) < 2 {
-		l.Error("invalid delete item format", zap.String("data", cq.Data))
-		h.Bot.EditMessage(cq.Message.Chat.ID, cq.Message.MessageID, MsgInvalidItem)
-		return nil
-	}
+	l := h.logger(ctx)
 
-	order, err := h.Repo.GetActiveOrder(context.Background(), int32(cq.Message.Chat.ID))
+	order, err := h.Repo.GetActiveOrder(ctx, cq.Message.Chat.ID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			h.Bot.EditMessage(cq.Message.Chat.ID, cq.Message.MessageID, MsgNoActiveOrders)
+			h.editMessage(ctx, cq.Message.Chat.ID, cq.Message.MessageID, h.t(ctx).T(MsgNoActiveOrders))
 			return nil
 		}
 		l.Error("failed to retrieve active order", zap.Error(err))
 		return err
 	}
 
-	itemID, err := strconv.Atoi(split[1])
+	itemID, err := strconv.Atoi(cmd.Arg("id"))
 	if err != nil {
 		l.Error("invalid item id", zap.String("data", cq.Data), zap.Error(err))
-		h.Bot.EditMessage(cq.Message.Chat.ID, cq.Message.MessageID, MsgInvalidItem)
+		h.editMessage(ctx, cq.Message.Chat.ID, cq.Message.MessageID, h.t(ctx).T(MsgInvalidItem))
 		return nil
 	}
 
-	item, err := h.Repo.DeleteItemByUser(context.Background(), models.DeleteItemByUserParams{
-		ID:     int32(itemID),
-		UserID: int32(cq.From.ID),
+	// items are only soft deleted, so the event can still refer to them
+	var item models.Item
+	err = h.Repo.ExecTx(ctx, func(q models.Querier) error {
+		item, err = q.DeleteItemByUser(ctx, models.DeleteItemByUserParams{
+			ID:     int32(itemID),
+			UserID: cq.From.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		event := h.newOrderEvent(item.OrderID, models.OrderEventDeleteItem, cq.From.ID, updateID)
+		event.ItemID = sql.NullInt32{Int32: item.ID, Valid: true}
+		event.ItemName = item.Name
+		event.Quantity = item.Quantity
+		_, err = q.CreateOrderEvent(ctx, event)
+		return err
 	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			h.Bot.EditMessage(cq.Message.Chat.ID, cq.Message.MessageID, MsgInvalidItem)
+			h.editMessage(ctx, cq.Message.Chat.ID, cq.Message.MessageID, h.t(ctx).T(MsgInvalidItem))
 			return nil
 		}
 		l.Error("failed to delete item", zap.Error(err))
 		return err
 	}
 
-	h.Bot.EditMessage(cq.Message.Chat.ID, cq.Message.MessageID, MsgDeletedOrder(int(item.Quantity), item.Name))
+	h.editMessage(ctx, cq.Message.Chat.ID, cq.Message.MessageID, h.t(ctx).T(MsgDeletedOrder, h.t(ctx).C(MsgItemCount, int(item.Quantity)), item.Name))
 
-	return h.sendOverview(l, order, false)
+	return h.sendOverview(ctx, order, false)
 }
 
-func (h *Handlers) handleCancelDeleteOrder(cq models.CallbackQuery) error {
+func (h *Handlers) handleCancelDeleteOrder(ctx context.Context, cq models.CallbackQuery) error {
 	if cq.Message != nil {
-		msg := tgbotapi.EditMessageTextConfig{
-			BaseEdit: tgbotapi.BaseEdit{
-				ChatID:    cq.Message.Chat.ID,
-				MessageID: cq.Message.MessageID,
-			},
-			Text: MsgCanceledDeleteOrderRequest,
-		}
-		h.Bot.BotAPI.Send(msg)
+		return h.editMessage(ctx, cq.Message.Chat.ID, cq.Message.MessageID, h.t(ctx).T(MsgCanceledDeleteOrderRequest))
 	}
 	return nil
 }
 
-func (h *Handlers) handleNewChatMembers(chatID int64, newChatMembers []models.User) {
-	l := h.Logger.With(zap.Int64("chat_id", chatID), zap.String("event", "new members"))
+func (h *Handlers) handleNewChatMembers(ctx context.Context, chatID int64, newChatMembers []models.User) {
+	l := h.logger(ctx).With(zap.String("event", "new members"))
 
 	botID, err := strconv.Atoi(strings.Split(h.BotToken, ":")[0])
 	if err != nil {
@@ -564,7 +657,7 @@ func (h *Handlers) handleNewChatMembers(chatID int64, newChatMembers []models.Us
 	}
 	for _, member := range newChatMembers {
 		if member.IsBot && member.ID == int64(botID) {
-			h.handleStart(chatID)
+			h.handleStart(ctx, chatID)
 		}
 	}
 }
@@ -0,0 +1,558 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/cmd/api/handlers"
+	"github.com/gpng/order-bot/cmd/api/handlers/handlerstest"
+	"github.com/gpng/order-bot/services/metrics"
+	"github.com/gpng/order-bot/services/telegram"
+	"github.com/gpng/order-bot/services/telegram/telegramtest"
+	"github.com/gpng/order-bot/sqlc/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var (
+	group = models.Chat{ID: -1001, Type: "group"}
+	alice = models.User{ID: 1001, FirstName: "Alice"}
+	bob   = models.User{ID: 1002, FirstName: "Bob"}
+)
+
+// en translates the message like the bot does for users without a supported language
+func en(key string, params ...interface{}) string {
+	return handlers.Messages.Translator("en").T(key, params...)
+}
+
+func TestOrderScenario(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/order kopi")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNoActiveOrders))
+
+	h.SendText(group, alice, "/takeorders Coffeeshop")
+	h.ExpectLastSent(group.ID, "Taking orders for Coffeeshop")
+
+	h.SendText(group, alice, "/takeorders Another")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNewTakeOrderExistingOrder, "Coffeeshop"))
+
+	h.SendText(group, alice, "/order 2 kopi o")
+	h.SendText(group, bob, "/order teh")
+	h.SendText(group, alice, "/order Kopi O")
+	h.ExpectLastSent(group.ID, "Alice</a> 3 x kopi o", "Bob</a> 1 x teh", "3 x kopi o\n")
+
+	h.SendText(group, alice, "/cancelorder")
+	keyboard := h.ExpectLastSent(group.ID, en(handlers.MsgSelectDeleteOrder))
+	if _, ok := keyboard.Button("1 x teh"); ok {
+		t.Fatalf("expected only alice's items in keyboard")
+	}
+
+	h.Press(alice, keyboard, "3 x kopi o")
+	edited := h.Telegram.Edited(group.ID)
+	if len(edited) != 1 || edited[0].Text != "Deleted 3 orders of kopi o" {
+		t.Fatalf("expected delete confirmation edit, got %+v", edited)
+	}
+	overview := h.ExpectLastSent(group.ID, "1 x teh")
+	if strings.Contains(overview.Text, "kopi o") {
+		t.Fatalf("expected kopi o to be deleted, got:\n%s", overview.Text)
+	}
+
+	h.SendText(group, alice, "/endorders")
+	h.ExpectLastSent(group.ID, en(handlers.MsgCancelTakeOrders))
+
+	h.SendText(group, bob, "/order teh")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNoActiveOrders))
+}
+
+func TestOrderExpiry(t *testing.T) {
+	h := handlerstest.New(t)
+
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry := h.Clock.Now().In(location).Add(30 * time.Minute).Format("15:04")
+
+	h.SendText(group, alice, "/takeorders "+expiry+" Coffeeshop")
+	h.SendText(group, alice, "/order kopi")
+	if n := h.Scheduler.Pending(); n != 2 {
+		t.Fatalf("expected reminder and expiry jobs, got %d", n)
+	}
+
+	h.Advance(26 * time.Minute)
+	h.ExpectLastSent(group.ID, "REMINDER", expiry+" in 5 minutes", "1 x kopi")
+
+	h.Advance(5 * time.Minute)
+	h.ExpectLastSent(group.ID, en(handlers.MsgCancelTakeOrders))
+
+	h.SendText(group, bob, "/order teh")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNoActiveOrders))
+}
+
+func TestOrderExpiryTomorrowAtMonthEnd(t *testing.T) {
+	h := handlerstest.New(t)
+
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Clock.Set(time.Date(2021, 1, 31, 23, 0, 0, 0, location))
+
+	h.SendText(group, alice, "/takeorders 00:30 Supper")
+	h.ExpectLastSent(group.ID, "ending at 00:30 tomorrow")
+
+	h.SendText(group, alice, "/checkorder")
+	h.ExpectLastSent(group.ID, "00:30 tomorrow")
+
+	// the reminder is sent after midnight, when the expiry is today
+	h.Advance(85 * time.Minute)
+	reminder := h.ExpectLastSent(group.ID, "REMINDER", "00:30 in 5 minutes")
+	if strings.Contains(reminder.Text, "tomorrow") {
+		t.Fatalf("expected expiry to be today, got:\n%s", reminder.Text)
+	}
+
+	h.Advance(5 * time.Minute)
+	h.ExpectLastSent(group.ID, en(handlers.MsgCancelTakeOrders))
+}
+
+func TestEndOrdersCancelsJobs(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/takeorders 23:59 Coffeeshop")
+	if n := h.Scheduler.Pending(); n == 0 {
+		t.Fatalf("expected scheduled jobs")
+	}
+
+	h.SendText(group, alice, "/endorders")
+	if n := h.Scheduler.Pending(); n != 0 {
+		t.Fatalf("expected jobs to be cancelled, got %d", n)
+	}
+}
+
+func TestSupergroupOrders(t *testing.T) {
+	h := handlerstest.New(t)
+	supergroup := models.Chat{ID: -1001234567890, Type: "supergroup"}
+	carol := models.User{ID: 5000000000, FirstName: "Carol"}
+
+	h.SendText(supergroup, carol, "/takeorders Coffeeshop")
+	h.SendText(supergroup, carol, "/order kopi")
+	h.ExpectLastSent(supergroup.ID, `<a href="tg://user?id=5000000000">Carol</a> 1 x kopi`)
+
+	h.SendText(supergroup, carol, "/cancelorder")
+	keyboard := h.ExpectLastSent(supergroup.ID, en(handlers.MsgSelectDeleteOrder))
+	if _, ok := keyboard.Button("1 x kopi"); !ok {
+		t.Fatalf("expected carol's item in keyboard")
+	}
+}
+
+func TestWrappedIDsAreRelinked(t *testing.T) {
+	h := handlerstest.New(t)
+	ctx := context.Background()
+	supergroup := models.Chat{ID: -1001234567890, Type: "supergroup"}
+	carol := models.User{ID: 5000000000, FirstName: "Carol"}
+	// truncates to the id of group, which has orders of its own
+	clash := models.Chat{ID: group.ID - 1<<32, Type: "supergroup"}
+
+	// stored by older versions, which truncated ids to 32 bits
+	order, err := h.Repo.CreateOrder(ctx, models.CreateOrderParams{
+		ChatID: int64(int32(supergroup.ID)),
+		Title:  "Coffeeshop",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Repo.UpsertUser(ctx, models.UpsertUserParams{
+		ID:        int64(int32(carol.ID)),
+		FirstName: carol.FirstName,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.Repo.CreateItem(ctx, models.CreateItemParams{
+		OrderID:  order.ID,
+		Quantity: 1,
+		Name:     "kopi",
+		UserID:   int64(int32(carol.ID)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// updates don't relink, the ids are only known once seen
+	h.SendText(supergroup, carol, "/checkorder")
+	h.ExpectLastSent(supergroup.ID, en(handlers.MsgNoActiveOrders))
+	h.SendText(group, alice, "/takeorders Lunch")
+	h.SendText(clash, alice, "/checkorder")
+
+	summary, err := handlers.RelinkWrappedIDs(ctx, h.Repo, zap.NewNop())
+	if err != nil || summary != (handlers.RelinkSummary{Chats: 1, Users: 1, Skipped: 1}) {
+		t.Fatalf("RelinkWrappedIDs = %+v, %v, want 1 chat and 1 user relinked and the clash skipped", summary, err)
+	}
+
+	h.SendText(supergroup, carol, "/order teh")
+	h.ExpectLastSent(supergroup.ID, "Coffeeshop", `<a href="tg://user?id=5000000000">Carol</a> 1 x kopi`, "1 x teh")
+	h.SendText(group, alice, "/checkorder")
+	h.ExpectLastSent(group.ID, "Lunch")
+
+	summary, err = handlers.RelinkWrappedIDs(ctx, h.Repo, zap.NewNop())
+	if err != nil || summary != (handlers.RelinkSummary{Skipped: 1}) {
+		t.Fatalf("RelinkWrappedIDs again = %+v, %v, want only the clash skipped", summary, err)
+	}
+}
+
+func TestGroupUpgradedToSupergroup(t *testing.T) {
+	h := handlerstest.New(t)
+	supergroup := models.Chat{ID: -1001234567890, Type: "supergroup"}
+
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry := h.Clock.Now().In(location).Add(30 * time.Minute).Format("15:04")
+
+	h.Telegram.SetAdmin(group.ID, alice.ID)
+	h.SendText(group, alice, "/language zh")
+	h.SendText(group, alice, "/takeorders "+expiry+" Coffeeshop")
+	h.SendText(group, alice, "/order kopi")
+
+	h.SendUpdate(models.TelegramUpdate{
+		Message: &models.Message{MessageID: 100, Chat: group, From: alice, MigrateToChatID: supergroup.ID},
+	})
+	h.SendUpdate(models.TelegramUpdate{
+		Message: &models.Message{MessageID: 1, Chat: supergroup, From: alice, MigrateFromChatID: group.ID},
+	})
+
+	// the order and the chat language follow the chat
+	h.SendText(supergroup, bob, "/order teh")
+	h.ExpectLastSent(supergroup.ID, "<b>汇总</b>", "Coffeeshop", "Alice</a> 1 x kopi", "Bob</a> 1 x teh")
+
+	h.Advance(31 * time.Minute)
+	h.ExpectLastSent(supergroup.ID, "已停止接单")
+}
+
+func TestOverviewShowsCurrentNames(t *testing.T) {
+	h := handlerstest.New(t)
+	dave := models.User{ID: 1003, Username: "dave"}
+
+	h.SendText(group, alice, "/takeorders Coffeeshop")
+	h.SendText(group, alice, "/order kopi")
+	h.SendText(group, dave, "/order teh")
+	h.ExpectLastSent(group.ID, "Alice</a> 1 x kopi", "@dave</a> 1 x teh")
+
+	renamed := models.User{ID: alice.ID, FirstName: "Alice", LastName: "<Tan>"}
+	h.SendText(group, renamed, "/checkorder")
+	h.ExpectLastSent(group.ID, "Alice &lt;Tan&gt;</a> 1 x kopi")
+
+	chat, err := h.Repo.GetChat(context.Background(), group.ID)
+	if err != nil || chat.Type != group.Type {
+		t.Fatalf("GetChat = %+v, %v, want the group", chat, err)
+	}
+}
+
+func TestOrderLog(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/log")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNoActiveOrders))
+
+	h.SendText(group, alice, "/takeorders Coffeeshop")
+	h.SendText(group, alice, "/order 2 kopi")
+	h.SendText(group, bob, "/order teh")
+	h.SendText(group, alice, "/order kopi")
+
+	h.SendText(group, alice, "/cancelorder")
+	keyboard := h.ExpectLastSent(group.ID, en(handlers.MsgSelectDeleteOrder))
+	h.Press(alice, keyboard, "3 x kopi")
+
+	h.SendText(group, bob, "/log")
+	log := h.ExpectLastSent(group.ID,
+		"Alice started taking orders\n",
+		"Alice ordered 2 x kopi\n",
+		"Bob ordered 1 x teh\n",
+		"Alice ordered 1 more x kopi\n",
+		"Alice deleted 3 x kopi\n",
+	)
+	if i, j := strings.Index(log.Text, "started"), strings.Index(log.Text, "deleted"); i > j {
+		t.Fatalf("expected events oldest first, got:\n%s", log.Text)
+	}
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at := h.Clock.Now().In(location).Format("15:04"); !strings.Contains(log.Text, at+" ") {
+		t.Fatalf("expected events at %s in the bot's time zone, got:\n%s", at, log.Text)
+	}
+
+	// deleted items stay out of the overview
+	h.SendText(group, bob, "/checkorder")
+	overview := h.ExpectLastSent(group.ID, "1 x teh")
+	if strings.Contains(overview.Text, "kopi") {
+		t.Fatalf("expected kopi to be deleted, got:\n%s", overview.Text)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	h := handlerstest.New(t)
+	chen := models.User{ID: 1003, FirstName: "Chen", LanguageCode: "zh-hans"}
+
+	// defaults to the language of each user
+	h.SendText(group, chen, "/checkorder")
+	h.ExpectLastSent(group.ID, "没有进行中的订单")
+	h.SendText(group, bob, "/checkorder")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNoActiveOrders))
+
+	h.SendText(group, bob, "/language")
+	h.ExpectLastSent(group.ID, en(handlers.MsgLanguageCurrentAuto), "en, zh, ms, id")
+
+	h.SendText(group, bob, "/language fr")
+	h.ExpectLastSent(group.ID, "Unsupported language!")
+
+	h.SendText(group, bob, "/language zh")
+	h.ExpectLastSent(group.ID, en(handlers.MsgLanguageAdminOnly))
+
+	h.Telegram.SetAdmin(group.ID, bob.ID)
+	h.SendText(group, bob, "/language ZH")
+	h.ExpectLastSent(group.ID, "这个聊天的消息现在使用中文")
+
+	// the chat language applies to every user
+	h.SendText(group, bob, "/takeorders 12:30 Coffeeshop")
+	h.ExpectLastSent(group.ID, "开始接单：Coffeeshop，12:30 截止")
+	h.SendText(group, bob, "/order 2 kopi")
+	h.ExpectLastSent(group.ID, "<b>汇总</b>", "2 x kopi")
+
+	h.SendText(group, bob, "/cancelorder")
+	keyboard := h.ExpectLastSent(group.ID, "选择要删除的点单")
+	h.Press(bob, keyboard, "2 x kopi")
+	edited := h.Telegram.Edited(group.ID)
+	if len(edited) != 1 || edited[0].Text != "已删除 2 份 kopi" {
+		t.Fatalf("expected delete confirmation edit, got %+v", edited)
+	}
+
+	h.SendText(group, bob, "/language auto")
+	h.ExpectLastSent(group.ID, en(handlers.MsgLanguageSetAuto))
+	h.SendText(group, chen, "/endorders")
+	h.ExpectLastSent(group.ID, "已停止接单")
+}
+
+func TestJobLanguage(t *testing.T) {
+	h := handlerstest.New(t)
+	chen := models.User{ID: 1003, FirstName: "Chen", LanguageCode: "zh-hans"}
+
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry := h.Clock.Now().In(location).Add(30 * time.Minute).Format("15:04")
+
+	// without /language, jobs use the language of the user who took orders
+	h.SendText(group, chen, "/takeorders "+expiry+" Coffeeshop")
+	h.SendText(group, bob, "/order kopi")
+	h.ExpectLastSent(group.ID, "Consolidated")
+
+	h.Advance(26 * time.Minute)
+	h.ExpectLastSent(group.ID, "提醒", expiry+"，5 分钟后截止", "1 x kopi")
+
+	h.Advance(5 * time.Minute)
+	h.ExpectLastSent(group.ID, "已停止接单")
+}
+
+func TestCommandParsing(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/takeorders@HelpMeBuyLehBot   12:30 \"Kopi  Toast\"")
+	h.ExpectLastSent(group.ID, "Taking orders for Kopi  Toast, ending at 12:30")
+
+	h.SendText(group, alice, "/order\n2   kopi   o")
+	h.ExpectLastSent(group.ID, "2 x kopi o")
+	// a quote in a word is not the start of quoted text
+	h.SendText(group, alice, "/order 1 12\" pizza")
+	h.ExpectLastSent(group.ID, "1 x 12&#34; pizza")
+
+	// commands of other bots in the group are ignored
+	sent := len(h.Telegram.Sent(group.ID))
+	h.SendText(group, alice, "/order@OtherBot teh")
+	if got := len(h.Telegram.Sent(group.ID)); got != sent {
+		t.Fatalf("expected no reply to another bot's command, got %d messages", got-sent)
+	}
+
+	h.SendText(group, bob, "/order")
+	h.ExpectLastSent(group.ID, "Missing item! Use /order [quantity] <item>")
+	h.SendText(group, bob, "/order 2")
+	h.ExpectLastSent(group.ID, "Missing item! Use /order [quantity] <item>")
+	h.SendText(group, bob, "/order -1 teh")
+	h.ExpectLastSent(group.ID, en(handlers.MsgOrderInvalidQuantity))
+	h.SendText(group, bob, "/order 99999999999 teh")
+	h.ExpectLastSent(group.ID, en(handlers.MsgOrderInvalidQuantity))
+	h.SendText(group, bob, "/takeorders")
+	h.ExpectLastSent(group.ID, "Missing title! Use /takeorders [time] [title]")
+	h.SendText(group, bob, "/order \"teh")
+	h.ExpectLastSent(group.ID, "Missing closing quote!")
+	h.SendText(group, bob, "/apikey rotate")
+	h.ExpectLastSent(group.ID, "Invalid action rotate! Use /apikey <action> [id]")
+
+	// a zero quantity is part of the item, as it was before quantities were parsed
+	h.SendText(group, bob, "/order 0 teh")
+	h.ExpectLastSent(group.ID, "1 x 0 teh")
+
+	h.SendText(group, bob, "/log limit=1")
+	log := h.ExpectLastSent(group.ID, "Bob ordered 1 x 0 teh")
+	if strings.Contains(log.Text, "started taking orders") {
+		t.Fatalf("expected only the latest event, got:\n%s", log.Text)
+	}
+	h.SendText(group, bob, "/log limit=all")
+	h.ExpectLastSent(group.ID, "Invalid limit=all! Use /log [limit=…]")
+
+	h.SendText(group, bob, "/help")
+	h.ExpectLastSent(group.ID,
+		"/takeorders [time] [title] - "+en(handlers.MsgHelpTakeOrders),
+		"/order [quantity] <item> - "+en(handlers.MsgHelpOrder),
+	)
+}
+
+func TestTakeOrdersWithoutTitle(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/takeorders 12:30")
+	h.ExpectLastSent(group.ID, "Taking orders for , ending at 12:30")
+	h.SendText(group, bob, "/order kopi")
+	h.ExpectLastSent(group.ID, "1 x kopi")
+}
+
+func TestInvalidCallbackData(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/takeorders Coffeeshop")
+	h.SendUpdate(models.TelegramUpdate{
+		CallbackQuery: &models.CallbackQuery{
+			ID:      "callback-invalid",
+			From:    alice,
+			Data:    "/delete kopi",
+			Message: &models.Message{MessageID: 1, Chat: group},
+		},
+	})
+	edited := h.Telegram.Edited(group.ID)
+	if len(edited) != 1 || edited[0].Text != en(handlers.MsgInvalidItem) {
+		t.Fatalf("expected invalid item edit, got %+v", edited)
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	h := handlerstest.New(t)
+
+	bot, err := telegram.NewWithEndpoint(telegramtest.BotToken, h.Telegram.Endpoint())
+	if err != nil {
+		t.Fatalf("failed to initialise bot: %v", err)
+	}
+	if err := h.Handlers.RegisterCommands(context.Background(), bot); err != nil {
+		t.Fatalf("RegisterCommands: %v", err)
+	}
+
+	tests := []struct {
+		languageCode string
+		want         string
+	}{
+		{"", en(handlers.MsgHelpTakeOrders)},
+		{"zh", handlers.Messages.Translator("zh").T(handlers.MsgHelpTakeOrders)},
+	}
+	for _, tt := range tests {
+		commands := h.Telegram.Commands(tt.languageCode)
+		if len(commands) == 0 || commands[2].Command != "takeorders" || commands[2].Description != tt.want {
+			t.Fatalf("%q commands = %+v, want takeorders described as %q", tt.languageCode, commands, tt.want)
+		}
+	}
+}
+
+func TestCommandsAreCounted(t *testing.T) {
+	h := handlerstest.New(t)
+	ordered := metrics.Commands.WithLabelValues("/order", metrics.OutcomeOK)
+	before := testutil.ToFloat64(ordered)
+
+	h.SendText(group, alice, "/takeorder Coffeeshop")
+	h.SendText(group, alice, "/order kopi")
+	h.SendText(group, alice, "hello")
+
+	if got := testutil.ToFloat64(ordered) - before; got != 1 {
+		t.Fatalf("counted %v /order commands, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.Commands.WithLabelValues("/takeorders", metrics.OutcomeOK)); got < 1 {
+		t.Fatalf("expected /takeorder to be counted as /takeorders")
+	}
+}
+
+func TestRedeliveredUpdateIsProcessedOnce(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/takeorders Coffeeshop")
+
+	update := models.TelegramUpdate{
+		UpdateID: 1000,
+		Message:  &models.Message{MessageID: 1000, Chat: group, From: alice, Text: "/order 2 kopi"},
+	}
+	h.SendUpdate(update)
+	h.SendUpdate(update)
+
+	h.SendText(group, alice, "/checkorder")
+	h.ExpectLastSent(group.ID, "2 x kopi\n")
+}
+
+func TestRedeliveredFailedUpdateIsNotApplied(t *testing.T) {
+	h := handlerstest.New(t)
+
+	h.SendText(group, alice, "/takeorders Coffeeshop")
+
+	// the item is saved before the overview fails to send, so the update must not run again
+	update := models.TelegramUpdate{
+		UpdateID: 1000,
+		Message:  &models.Message{MessageID: 1000, Chat: group, From: alice, Text: "/order 2 kopi"},
+	}
+	h.Telegram.Fail("sendMessage", telegramtest.Failure{Code: http.StatusBadRequest, Description: "Bad Request: message is too long"})
+	h.SendUpdate(update)
+	h.ExpectLastSent(group.ID, en(handlers.MsgError))
+	h.SendUpdate(update)
+
+	h.SendText(group, alice, "/checkorder")
+	h.ExpectLastSent(group.ID, "2 x kopi\n")
+}
+
+func TestUpdateLogsCarryUpdateFields(t *testing.T) {
+	h := handlerstest.New(t)
+	core, logs := observer.New(zap.InfoLevel)
+	h.Handlers.Logger = zap.New(core)
+
+	update := models.TelegramUpdate{
+		UpdateID: 1000,
+		Message:  &models.Message{MessageID: 1000, Chat: group, From: alice, Text: "/Order 2 kopi"},
+	}
+	h.SendUpdate(update)
+	h.SendUpdate(update)
+
+	skipped := logs.FilterMessage("skipping duplicate update").All()
+	if len(skipped) != 1 {
+		t.Fatalf("expected the redelivered update to be logged once, got %+v", logs.All())
+	}
+	fields := skipped[0].ContextMap()
+	if fields["update_id"] != int64(1000) || fields["chat_id"] != group.ID || fields["user_id"] != alice.ID || fields["command"] != "/order" {
+		t.Fatalf("expected update fields in log, got %+v", fields)
+	}
+}
+
+func TestWebhookRejectsInvalidSecret(t *testing.T) {
+	h := handlerstest.New(t)
+
+	body, _ := json.Marshal(models.TelegramUpdate{
+		UpdateID: 1,
+		Message:  &models.Message{Chat: group, From: alice, Text: "/start"},
+	})
+	if code := h.Post(body, "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, code)
+	}
+	if code := h.Post(body, ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, code)
+	}
+}
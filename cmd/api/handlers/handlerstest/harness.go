@@ -0,0 +1,289 @@
+package handlerstest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/gpng/order-bot/cmd/api/config"
+	"github.com/gpng/order-bot/cmd/api/handlers"
+	"github.com/gpng/order-bot/services/clock"
+	"github.com/gpng/order-bot/services/dedup"
+	"github.com/gpng/order-bot/services/health"
+	"github.com/gpng/order-bot/services/scheduler"
+	"github.com/gpng/order-bot/services/telegram"
+	"github.com/gpng/order-bot/services/telegram/telegramtest"
+	"github.com/gpng/order-bot/services/workerpool"
+	"github.com/gpng/order-bot/sqlc/models"
+	"github.com/gpng/order-bot/sqlc/models/memory"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/time/rate"
+)
+
+// test dependencies
+const (
+	redisNamespace = "order_bot_test"
+	webhookPath    = "/webhook"
+	webhookSecret  = "test-secret"
+	timezone       = "Asia/Singapore"
+	waitTimeout    = 10 * time.Second
+)
+
+// Harness feeds telegram updates through the webhook into the handlers, backed by a fake telegram server,
+// an in-memory repo and an in-memory scheduler. Redis is real and configured like the app.
+type Harness struct {
+	Telegram  *telegramtest.Server
+	Handlers  *handlers.Handlers
+	Repo      *memory.Queries
+	Clock     *clock.Fake
+	Scheduler *scheduler.Memory
+
+	t       *testing.T
+	router  http.Handler
+	updates *workerpool.Pool
+
+	mu           sync.Mutex
+	nextUpdateID int
+	nextMessage  int
+}
+
+// New harness with an empty repo, skipping the test if redis is unavailable
+func New(t *testing.T) *Harness {
+	t.Helper()
+
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatalf("failed to load env vars: %v", err)
+	}
+
+	redisPool := &redis.Pool{
+		MaxActive: 5,
+		MaxIdle:   5,
+		Wait:      true,
+		Dial: func() (redis.Conn, error) {
+			return redis.DialURL(cfg.RedisURL, redis.DialPassword(cfg.RedisPassword))
+		},
+	}
+	t.Cleanup(func() { redisPool.Close() })
+	resetRedis(t, redisPool)
+
+	server := telegramtest.NewServer()
+	t.Cleanup(server.Close)
+
+	bot, err := telegram.NewWithEndpoint(telegramtest.BotToken, server.Endpoint())
+	if err != nil {
+		t.Fatalf("failed to initialise bot: %v", err)
+	}
+	bot.SetRateLimits(rate.Inf, rate.Inf)
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	repo := memory.New()
+	fakeClock := clock.NewFake(time.Now())
+	jobScheduler := scheduler.NewMemory(fakeClock)
+	logger := zaptest.NewLogger(t)
+	updates := workerpool.New(1, 100, logger)
+	t.Cleanup(func() { updates.Shutdown(context.Background()) })
+
+	checker := health.New(time.Second)
+	checker.Add("telegram", bot.Ping)
+
+	h := handlers.New(
+		telegramtest.BotToken,
+		webhookPath,
+		webhookSecret,
+		location,
+		logger,
+		nil,
+		repo,
+		bot,
+		jobScheduler,
+		fakeClock,
+		dedup.NewRedis(redisPool, redisNamespace, time.Hour),
+		updates,
+		checker,
+	)
+
+	return &Harness{
+		Telegram:     server,
+		Handlers:     h,
+		Repo:         repo,
+		Clock:        fakeClock,
+		Scheduler:    jobScheduler,
+		t:            t,
+		router:       h.Routes(),
+		updates:      updates,
+		nextUpdateID: 1,
+		nextMessage:  1,
+	}
+}
+
+// SendText sends a text message from user in chat and waits for it to be processed
+func (h *Harness) SendText(chat models.Chat, user models.User, text string) {
+	h.t.Helper()
+	h.mu.Lock()
+	messageID := h.nextMessage
+	h.nextMessage++
+	h.mu.Unlock()
+
+	h.SendUpdate(models.TelegramUpdate{
+		Message: &models.Message{
+			MessageID: messageID,
+			Chat:      chat,
+			From:      user,
+			Text:      text,
+		},
+	})
+}
+
+// Press presses an inline keyboard button of a sent message as user and waits for it to be processed
+func (h *Harness) Press(user models.User, message telegramtest.Message, button string) {
+	h.t.Helper()
+	data, ok := message.Button(button)
+	if !ok {
+		h.t.Fatalf("message %q has no button %q", message.Text, button)
+	}
+
+	h.SendUpdate(models.TelegramUpdate{
+		CallbackQuery: &models.CallbackQuery{
+			ID:   "callback-" + button,
+			From: user,
+			Data: data,
+			Message: &models.Message{
+				MessageID: message.MessageID,
+				Chat:      models.Chat{ID: message.ChatID},
+				Text:      message.Text,
+			},
+		},
+	})
+}
+
+// SendUpdate posts the update to the webhook and waits for it to be processed, a zero UpdateID is assigned the next id
+func (h *Harness) SendUpdate(update models.TelegramUpdate) {
+	h.t.Helper()
+	if update.UpdateID == 0 {
+		h.mu.Lock()
+		update.UpdateID = h.nextUpdateID
+		h.nextUpdateID++
+		h.mu.Unlock()
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		h.t.Fatalf("failed to encode update: %v", err)
+	}
+	if code := h.Post(body, webhookSecret); code != http.StatusOK {
+		h.t.Fatalf("webhook responded with %d", code)
+	}
+
+	h.Wait(update)
+}
+
+// Post sends a raw webhook request with the secret token header and returns the status code
+func (h *Harness) Post(body []byte, secret string) int {
+	req := httptest.NewRequest(http.MethodPost, webhookPath, bytes.NewReader(body))
+	req.Header.Set(telegram.SecretTokenHeader, secret)
+	rec := httptest.NewRecorder()
+	h.router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// Get requests path from the router
+func (h *Harness) Get(path string) *httptest.ResponseRecorder {
+	return h.GetWithAPIKey(path, "")
+}
+
+// GetWithAPIKey requests path from the router with key as a bearer token, if it is not empty
+func (h *Harness) GetWithAPIKey(path string, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	h.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// Wait blocks until updates queued before update's chat are processed, as each chat's updates run in order
+func (h *Harness) Wait(update models.TelegramUpdate) {
+	h.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+
+	var chatID int64
+	switch {
+	case update.Message != nil:
+		chatID = update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		chatID = update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil:
+		chatID = update.CallbackQuery.From.ID
+	}
+
+	done := make(chan struct{})
+	if err := h.updates.Submit(ctx, chatID, func() { close(done) }); err != nil {
+		h.t.Fatalf("failed to wait for update: %v", err)
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		h.t.Fatalf("timed out waiting for update %d", update.UpdateID)
+	}
+}
+
+// Advance moves the clock forward by d, running scheduled jobs that become due and failing the test if one errors
+func (h *Harness) Advance(d time.Duration) {
+	h.t.Helper()
+	if err := h.Scheduler.Advance(context.Background(), d, h.Handlers.RunJob); err != nil {
+		h.t.Fatalf("failed to run scheduled job: %v", err)
+	}
+}
+
+// LastSent returns the latest message sent to the chat, failing the test if there is none
+func (h *Harness) LastSent(chatID int64) telegramtest.Message {
+	h.t.Helper()
+	m, ok := h.Telegram.LastSent(chatID)
+	if !ok {
+		h.t.Fatalf("no messages sent to chat %d", chatID)
+	}
+	return m
+}
+
+// ExpectLastSent fails the test unless the latest message sent to the chat contains all of substrs
+func (h *Harness) ExpectLastSent(chatID int64, substrs ...string) telegramtest.Message {
+	h.t.Helper()
+	m := h.LastSent(chatID)
+	for _, s := range substrs {
+		if !strings.Contains(m.Text, s) {
+			h.t.Fatalf("expected last message to contain %q, got:\n%s", s, m.Text)
+		}
+	}
+	return m
+}
+
+// resetRedis removes the test namespace, including processed update ids
+func resetRedis(t *testing.T, pool *redis.Pool) {
+	t.Helper()
+	conn := pool.Get()
+	defer conn.Close()
+
+	keys, err := redis.Strings(conn.Do("KEYS", redisNamespace+":*"))
+	if err != nil {
+		t.Skipf("redis unavailable: %v", err)
+	}
+	for _, key := range keys {
+		if _, err := conn.Do("DEL", key); err != nil {
+			t.Fatalf("failed to reset redis: %v", err)
+		}
+	}
+}
@@ -2,8 +2,16 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/gocraft/work"
+	"github.com/gpng/order-bot/services/logger"
+	"github.com/gpng/order-bot/services/scheduler"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
@@ -12,28 +20,76 @@ const (
 	jobArgPreExpiry = "pre_expiry"
 )
 
-// JobNotifyExpiry sends an alert when job is done
+// reminders are sent reminderBefore expiry, only for orders with more than reminderMinLead to go
+const (
+	reminderBefore  = 5 * time.Minute
+	reminderMinLead = 10 * time.Minute
+)
+
+// RunJob runs a due scheduled job, whichever scheduler it came from
+func (h *Handlers) RunJob(ctx context.Context, job scheduler.Job) (err error) {
+	ctx, span := tracer.Start(ctx, "job "+job.Name)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	ctx = logger.WithContext(ctx, h.logger(ctx).With(zap.String("job", job.Name)))
+
+	switch JobName(job.Name) {
+	case JobNotifyExpiry:
+		return h.notifyExpiry(ctx, job)
+	}
+	return fmt.Errorf("unknown job %s", job.Name)
+}
+
+// JobNotifyExpiry runs notify expiry jobs from the gocraft/work worker pool
 func (h *Handlers) JobNotifyExpiry(job *work.Job) error {
+	return h.RunJob(context.Background(), scheduler.Job{Name: job.Name, Args: job.Args})
+}
+
+// notifyExpiry sends an alert when job is done
+func (h *Handlers) notifyExpiry(ctx context.Context, job scheduler.Job) error {
 	orderID := int32(job.ArgInt64(jobArgOrderID))
 	preExpiry := job.ArgBool(jobArgPreExpiry)
 
-	l := h.Logger.With(zap.String("job", string(JobNotifyExpiry)), zap.Int32("order_id", orderID))
+	l := h.logger(ctx).With(zap.Int32("order_id", orderID))
+	ctx = logger.WithContext(ctx, l)
 
-	order, err := h.Repo.GetOrderByID(context.Background(), orderID)
+	order, err := h.Repo.GetOrderByID(ctx, orderID)
+	// the order was rolled back after the job was scheduled
+	if errors.Is(err, sql.ErrNoRows) {
+		l.Info("skipping missing order")
+		return nil
+	}
 	if err != nil {
 		l.Error("failed to retrieve order", zap.Error(err))
 		return err
 	}
+	// the order was ended or expired by the reconciler after the job was scheduled
+	if !order.Active {
+		l.Info("skipping inactive order")
+		return nil
+	}
+	ctx = h.withLanguage(ctx, order.ChatID, order.LanguageCode)
 
-	err = h.sendOverview(l, order, preExpiry)
+	err = h.sendOverview(ctx, order, preExpiry)
 	if err != nil {
 		l.Error("failed to send notification", zap.Error(err))
 		return err
 	}
 	if !preExpiry {
-		h.Bot.SendMessage(int64(order.ChatID), false, MsgCancelTakeOrders)
+		h.sendMessage(ctx, order.ChatID, false, h.t(ctx).T(MsgCancelTakeOrders))
 
-		err = h.Repo.DeactivateOrder(context.Background(), orderID)
+		err = h.Repo.ExecTx(ctx, func(q models.Querier) error {
+			if err := q.DeactivateOrder(ctx, orderID); err != nil {
+				return err
+			}
+			_, err := q.CreateOrderEvent(ctx, h.newOrderEvent(orderID, models.OrderEventExpire, 0, 0))
+			return err
+		})
 		if err != nil {
 			l.Error("failed to deactivate order", zap.Error(err))
 			return err
@@ -42,3 +98,25 @@ func (h *Handlers) JobNotifyExpiry(job *work.Job) error {
 
 	return nil
 }
+
+// scheduleOrderJob schedules the reminder or expiry job of an order and saves its handle on the order with q
+func (h *Handlers) scheduleOrderJob(ctx context.Context, q models.Querier, order models.Order, preExpiry bool, at time.Time) error {
+	handle, err := h.Scheduler.Schedule(ctx, string(JobNotifyExpiry), at, map[string]interface{}{
+		jobArgOrderID:   int64(order.ID),
+		jobArgPreExpiry: preExpiry,
+	})
+	if err != nil {
+		return err
+	}
+	return saveOrderJob(ctx, q, order.ID, preExpiry, at, handle)
+}
+
+// saveOrderJob saves the handle of the reminder or expiry job on the order
+func saveOrderJob(ctx context.Context, q models.Querier, orderID int32, preExpiry bool, at time.Time, handle scheduler.Handle) error {
+	runAt := sql.NullInt64{Int64: at.Unix(), Valid: true}
+	id := sql.NullString{String: string(handle), Valid: true}
+	if preExpiry {
+		return q.UpdateReminder(ctx, models.UpdateReminderParams{ID: orderID, ReminderRunAt: runAt, ReminderID: id})
+	}
+	return q.UpdateExpiry(ctx, models.UpdateExpiryParams{ID: orderID, ExpiryRunAt: runAt, ExpiryID: id})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gpng/order-bot/services/command"
+	"github.com/gpng/order-bot/services/i18n"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+// languageAuto resets the chat to the telegram language of each user
+const languageAuto = "auto"
+
+// withLanguage carries the translator of the chat, set with /language, falling back to languageCode of the user.
+// Messages sent by jobs pass the languageCode of the user who took orders.
+func (h *Handlers) withLanguage(ctx context.Context, chatID int64, languageCode string) context.Context {
+	language := languageCode
+	chat, err := h.Repo.GetChat(ctx, chatID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		h.logger(ctx).Error("error fetching chat language", zap.Error(err))
+	}
+	if chat.Language != "" {
+		language = chat.Language
+	}
+	return i18n.WithContext(ctx, Messages.Translator(language))
+}
+
+func (h *Handlers) handleLanguage(ctx context.Context, chat models.Chat, cmd command.Command, user models.User) error {
+	l := h.logger(ctx)
+
+	if cmd.Arg("language") == "" {
+		return h.sendCurrentLanguage(ctx, chat.ID)
+	}
+
+	language := i18n.Normalize(cmd.Arg("language"))
+	if language == languageAuto {
+		language = ""
+	} else if !Messages.Supports(language) {
+		h.sendMessage(ctx, chat.ID, false, h.t(ctx).T(MsgLanguageUnsupported, strings.Join(Messages.Languages(), ", ")))
+		return nil
+	}
+
+	isAdmin, err := h.isChatAdmin(ctx, chat, user)
+	if err != nil {
+		l.Error("error checking chat admin", zap.Error(err))
+		return err
+	}
+	if !isAdmin {
+		h.sendMessage(ctx, chat.ID, false, h.t(ctx).T(MsgLanguageAdminOnly))
+		return nil
+	}
+
+	err = h.Repo.SetChatLanguage(ctx, models.SetChatLanguageParams{
+		ID:       chat.ID,
+		Language: language,
+	})
+	if err != nil {
+		l.Error("error setting chat language", zap.Error(err))
+		return err
+	}
+
+	// confirmed in the new language
+	if language == "" {
+		t := Messages.Translator(user.LanguageCode)
+		h.sendMessage(ctx, chat.ID, false, t.T(MsgLanguageSetAuto))
+		return nil
+	}
+	t := Messages.Translator(language)
+	h.sendMessage(ctx, chat.ID, false, t.T(MsgLanguageSet, t.T(MsgLanguageName)))
+	return nil
+}
+
+func (h *Handlers) sendCurrentLanguage(ctx context.Context, chatID int64) error {
+	t := h.t(ctx)
+
+	chat, err := h.Repo.GetChat(ctx, chatID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		h.logger(ctx).Error("error fetching chat language", zap.Error(err))
+		return err
+	}
+
+	current := t.T(MsgLanguageCurrentAuto)
+	if chat.Language != "" {
+		current = t.T(MsgLanguageCurrent, Messages.Translator(chat.Language).T(MsgLanguageName))
+	}
+	return h.sendMessage(ctx, chatID, false, current+"\n"+t.T(MsgLanguageUsage, strings.Join(Messages.Languages(), ", ")))
+}
@@ -1,32 +1,100 @@
 package handlers
 
-import "fmt"
+import (
+	"context"
 
-// messages
+	"github.com/gpng/order-bot/services/i18n"
+)
+
+// message keys, translated by the catalogues of Messages
+const (
+	MsgError                      = "error"
+	MsgIntro                      = "intro"
+	MsgHelp                       = "help"
+	MsgHelpStart                  = "help_start"
+	MsgHelpHelp                   = "help_help"
+	MsgHelpTakeOrders             = "help_take_orders"
+	MsgHelpOrder                  = "help_order"
+	MsgHelpCheckOrder             = "help_check_order"
+	MsgHelpCancelOrder            = "help_cancel_order"
+	MsgHelpEndOrders              = "help_end_orders"
+	MsgHelpLog                    = "help_log"
+	MsgHelpLanguage               = "help_language"
+	MsgHelpAPIKey                 = "help_api_key"
+	MsgCommandMissingArg          = "command_missing_arg"
+	MsgCommandInvalidArg          = "command_invalid_arg"
+	MsgCommandInvalidOption       = "command_invalid_option"
+	MsgCommandUnterminatedQuote   = "command_unterminated_quote"
+	MsgTakeOrders                 = "take_orders"
+	MsgEndTakeOrders              = "end_take_orders"
+	MsgOrder                      = "order"
+	MsgNewTakeOrderExistingOrder  = "new_take_order_existing_order"
+	MsgTakingOrders               = "taking_orders"
+	MsgTakingOrdersUntil          = "taking_orders_until"
+	MsgTakingOrdersUntilTomorrow  = "taking_orders_until_tomorrow"
+	MsgCancelTakeOrders           = "cancel_take_orders"
+	MsgNoActiveOrders             = "no_active_orders"
+	MsgOrderInvalidQuantity       = "order_invalid_quantity"
+	MsgNoOrders                   = "no_orders"
+	MsgNoExpiry                   = "no_expiry"
+	MsgExpiryTomorrow             = "expiry_tomorrow"
+	MsgExpirySoon                 = "expiry_soon"
+	MsgReminder                   = "reminder"
+	MsgConsolidated               = "consolidated"
+	MsgSelectDeleteOrder          = "select_delete_order"
+	MsgCancel                     = "cancel"
+	MsgInvalidItem                = "invalid_item"
+	MsgDeletedOrder               = "deleted_order"
+	MsgCanceledDeleteOrderRequest = "canceled_delete_order_request"
+	MsgCancelOrder                = "cancel_order"
+	MsgLog                        = "log"
+	MsgNoOrderEvents              = "no_order_events"
+	MsgBot                        = "bot"
+	MsgEventCreate                = "event_create"
+	MsgEventAddItem               = "event_add_item"
+	MsgEventChangeQuantity        = "event_change_quantity"
+	MsgEventDeleteItem            = "event_delete_item"
+	MsgEventClose                 = "event_close"
+	MsgEventExpire                = "event_expire"
+	MsgAPIKey                     = "api_key"
+	MsgAPIKeyInvalidFormat        = "api_key_invalid_format"
+	MsgAPIKeyAdminOnly            = "api_key_admin_only"
+	MsgAPIKeyCreated              = "api_key_created"
+	MsgAPIKeySent                 = "api_key_sent"
+	MsgAPIKeyStartPrivateChat     = "api_key_start_private_chat"
+	MsgAPIKeyNotFound             = "api_key_not_found"
+	MsgAPIKeyRevoked              = "api_key_revoked"
+	MsgAPIKeys                    = "api_keys"
+	MsgAPIKeyListItem             = "api_key_list_item"
+	MsgNoAPIKeys                  = "no_api_keys"
+	MsgLanguage                   = "language"
+	MsgLanguageName               = "language_name"
+	MsgLanguageCurrent            = "language_current"
+	MsgLanguageCurrentAuto        = "language_current_auto"
+	MsgLanguageSet                = "language_set"
+	MsgLanguageSetAuto            = "language_set_auto"
+	MsgLanguageUsage              = "language_usage"
+	MsgLanguageUnsupported        = "language_unsupported"
+	MsgLanguageAdminOnly          = "language_admin_only"
+)
+
+// plural message keys, translated with a count
 const (
-	MsgError                      = "Oops, something went wrong"
-	MsgTakeOrders                 = "Start taking orders using /takeorders 15:00 Coffeeshop Kopi"
-	MsgEndTakeOrders              = "Use /endorders to stop taking orders"
-	MsgOrder                      = "Add orders using /order 2 kopi o kosong"
-	MsgNewTakeOrderInvalidFormat  = "Invalid format! " + MsgTakeOrders
-	MsgNewTakeOrderInvalidTime    = "Invalid time! " + MsgTakeOrders
-	MsgCancelTakeOrders           = "Stopped taking orders"
-	MsgNoActiveOrders             = "No active orders! " + MsgTakeOrders
-	MsgOrderInvalidFormat         = "Invalid order! " + MsgOrder
-	MsgOrderInvalidQuantity       = "Invalid quantity! " + MsgOrder
-	MsgNoOrders                   = "You have no current orders"
-	MsgSelectDeleteOrder          = "Select order item to delete"
-	MsgInvalidItem                = "Invalid Item"
-	MsgCanceledDeleteOrderRequest = "Canceled cancel order request"
-	MsgCancelOrder                = "Cancel your order using /cancelorder"
+	MsgItemCount = "item_count"
 )
 
-// MsgNewTakeOrderExistingOrder message
-func MsgNewTakeOrderExistingOrder(title string) string {
-	return "There is already an existing order for " + title + ". " + MsgEndTakeOrders
+// Messages of every supported language, English is used for other languages
+var Messages = mustLoadMessages()
+
+func mustLoadMessages() *i18n.Bundle {
+	bundle, err := i18n.New(messagesEN, messagesZH, messagesMS, messagesID)
+	if err != nil {
+		panic("handlers: invalid messages: " + err.Error())
+	}
+	return bundle
 }
 
-// MsgDeletedOrder message
-func MsgDeletedOrder(quantity int, name string) string {
-	return fmt.Sprintf("Deleted order: %d x %s", quantity, name)
+// t is the translator of the chat of ctx, see withLanguage
+func (h *Handlers) t(ctx context.Context) i18n.Translator {
+	return i18n.FromContext(ctx, Messages.Translator(""))
 }
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/gpng/order-bot/services/i18n"
+)
+
+const (
+	enTakeOrders = "Start taking orders using /takeorders 15:00 Coffeeshop Kopi"
+	enEndOrders  = "Use /endorders to stop taking orders"
+	enOrder      = "Add orders using /order 2 kopi o kosong"
+	enAPIKey     = "Manage API keys using /apikey create, /apikey list or /apikey revoke 1"
+	enLanguage   = "Use /language followed by {0}, or /language auto to use the Telegram language of each user"
+)
+
+// messagesEN is the fallback catalogue, every other catalogue has its messages
+var messagesEN = i18n.Catalog{
+	Locale: en.New(),
+	Messages: map[string]string{
+		MsgError:                      "Oops, something went wrong",
+		MsgIntro:                      "Use HelpMeBuyLehBot to collect group orders!",
+		MsgHelp:                       "Commands of HelpMeBuyLehBot, optional args are in brackets:",
+		MsgHelpStart:                  "Show how to collect group orders",
+		MsgHelpHelp:                   "List all commands",
+		MsgHelpTakeOrders:             "Start taking orders, ending at an optional time such as 15:00",
+		MsgHelpOrder:                  "Add an item to the active order",
+		MsgHelpCheckOrder:             "Show the active order",
+		MsgHelpCancelOrder:            "Delete items you ordered",
+		MsgHelpEndOrders:              "Stop taking orders",
+		MsgHelpLog:                    "Show who changed the active order",
+		MsgHelpLanguage:               "Show or change the language of the chat",
+		MsgHelpAPIKey:                 "Create, list or revoke API keys of the chat",
+		MsgCommandMissingArg:          "Missing {0}! Use {1}",
+		MsgCommandInvalidArg:          "Invalid {0} {1}! Use {2}",
+		MsgCommandInvalidOption:       "Invalid {0}={1}! Use {2}",
+		MsgCommandUnterminatedQuote:   "Missing closing quote! Use {0}",
+		MsgTakeOrders:                 enTakeOrders,
+		MsgEndTakeOrders:              enEndOrders,
+		MsgOrder:                      enOrder,
+		MsgNewTakeOrderExistingOrder:  "There is already an existing order for {0}. " + enEndOrders,
+		MsgTakingOrders:               "Taking orders for {0}",
+		MsgTakingOrdersUntil:          "Taking orders for {0}, ending at {1}",
+		MsgTakingOrdersUntilTomorrow:  "Taking orders for {0}, ending at {1} tomorrow",
+		MsgCancelTakeOrders:           "Stopped taking orders",
+		MsgNoActiveOrders:             "No active orders! " + enTakeOrders,
+		MsgOrderInvalidQuantity:       "Invalid quantity! " + enOrder,
+		MsgNoOrders:                   "You have no current orders",
+		MsgNoExpiry:                   "No expiry",
+		MsgExpiryTomorrow:             "{0} tomorrow",
+		MsgExpirySoon:                 "{0} in 5 minutes",
+		MsgReminder:                   "REMINDER",
+		MsgConsolidated:               "Consolidated",
+		MsgSelectDeleteOrder:          "Select order item to delete",
+		MsgCancel:                     "Cancel",
+		MsgInvalidItem:                "Invalid Item",
+		MsgDeletedOrder:               "Deleted {0} of {1}",
+		MsgCanceledDeleteOrderRequest: "Canceled cancel order request",
+		MsgCancelOrder:                "Cancel your order using /cancelorder",
+		MsgLog:                        "See who changed the order using /log",
+		MsgNoOrderEvents:              "No changes recorded for this order",
+		MsgBot:                        "Bot",
+		MsgEventCreate:                "{0} {1} started taking orders",
+		MsgEventAddItem:               "{0} {1} ordered {2} x {3}",
+		MsgEventChangeQuantity:        "{0} {1} ordered {2} more x {3}",
+		MsgEventDeleteItem:            "{0} {1} deleted {2} x {3}",
+		MsgEventClose:                 "{0} {1} stopped taking orders",
+		MsgEventExpire:                "{0} Orders expired",
+		MsgAPIKey:                     enAPIKey,
+		MsgAPIKeyInvalidFormat:        "Invalid format! " + enAPIKey,
+		MsgAPIKeyAdminOnly:            "Only chat admins can manage API keys",
+		MsgAPIKeyCreated:              "API key {0} created, keep it secret as it will not be shown again:\n\n{1}",
+		MsgAPIKeySent:                 "API key created and sent to you privately",
+		MsgAPIKeyStartPrivateChat:     "Unable to send you the API key, start a private chat with me first and try again",
+		MsgAPIKeyNotFound:             "API key not found",
+		MsgAPIKeyRevoked:              "Revoked API key {0}",
+		MsgAPIKeys:                    "API keys",
+		MsgAPIKeyListItem:             "{0} - created {1} by {2}",
+		MsgNoAPIKeys:                  "No active API keys. Create one using /apikey create",
+		MsgLanguage:                   "Change the language of the bot using /language",
+		MsgLanguageName:               "English",
+		MsgLanguageCurrent:            "Messages in this chat are in {0}",
+		MsgLanguageCurrentAuto:        "Messages in this chat are in the Telegram language of each user",
+		MsgLanguageSet:                "Messages in this chat are now in {0}",
+		MsgLanguageSetAuto:            "Messages in this chat are now in the Telegram language of each user",
+		MsgLanguageUsage:              enLanguage,
+		MsgLanguageUnsupported:        "Unsupported language! " + enLanguage,
+		MsgLanguageAdminOnly:          "Only chat admins can change the language",
+	},
+	Plurals: map[string]map[locales.PluralRule]string{
+		MsgItemCount: {
+			locales.PluralRuleOne:   "{0} order",
+			locales.PluralRuleOther: "{0} orders",
+		},
+	},
+}
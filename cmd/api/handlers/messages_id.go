@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/id"
+	"github.com/gpng/order-bot/services/i18n"
+)
+
+const (
+	idTakeOrders = "Mulai terima pesanan dengan /takeorders 15:00 Warung Kopi"
+	idEndOrders  = "Gunakan /endorders untuk berhenti menerima pesanan"
+	idOrder      = "Tambah pesanan dengan /order 2 es teh manis"
+	idAPIKey     = "Kelola kunci API dengan /apikey create, /apikey list atau /apikey revoke 1"
+	idLanguage   = "Gunakan /language diikuti {0}, atau /language auto untuk memakai bahasa Telegram setiap pengguna"
+)
+
+var messagesID = i18n.Catalog{
+	Locale: id.New(),
+	Messages: map[string]string{
+		MsgError:                      "Waduh, ada yang salah",
+		MsgIntro:                      "Gunakan HelpMeBuyLehBot untuk mengumpulkan pesanan grup!",
+		MsgHelp:                       "Perintah HelpMeBuyLehBot, argumen opsional dalam kurung:",
+		MsgHelpStart:                  "Tampilkan cara mengumpulkan pesanan grup",
+		MsgHelpHelp:                   "Tampilkan semua perintah",
+		MsgHelpTakeOrders:             "Mulai menerima pesanan, bisa berakhir pada waktu seperti 15:00",
+		MsgHelpOrder:                  "Tambah pesanan ke pesanan aktif",
+		MsgHelpCheckOrder:             "Tampilkan pesanan aktif",
+		MsgHelpCancelOrder:            "Hapus pesananmu",
+		MsgHelpEndOrders:              "Berhenti menerima pesanan",
+		MsgHelpLog:                    "Tampilkan siapa yang mengubah pesanan aktif",
+		MsgHelpLanguage:               "Tampilkan atau ganti bahasa obrolan",
+		MsgHelpAPIKey:                 "Buat, tampilkan atau cabut kunci API obrolan",
+		MsgCommandMissingArg:          "{0} belum diisi! Gunakan {1}",
+		MsgCommandInvalidArg:          "{0} tidak valid: {1}! Gunakan {2}",
+		MsgCommandInvalidOption:       "{0}={1} tidak valid! Gunakan {2}",
+		MsgCommandUnterminatedQuote:   "Tanda kutip penutup tidak ada! Gunakan {0}",
+		MsgTakeOrders:                 idTakeOrders,
+		MsgEndTakeOrders:              idEndOrders,
+		MsgOrder:                      idOrder,
+		MsgNewTakeOrderExistingOrder:  "Pesanan untuk {0} sudah dibuka. " + idEndOrders,
+		MsgTakingOrders:               "Menerima pesanan untuk {0}",
+		MsgTakingOrdersUntil:          "Menerima pesanan untuk {0}, berakhir pukul {1}",
+		MsgTakingOrdersUntilTomorrow:  "Menerima pesanan untuk {0}, berakhir besok pukul {1}",
+		MsgCancelTakeOrders:           "Berhenti menerima pesanan",
+		MsgNoActiveOrders:             "Tidak ada pesanan aktif! " + idTakeOrders,
+		MsgOrderInvalidQuantity:       "Jumlah tidak valid! " + idOrder,
+		MsgNoOrders:                   "Kamu belum memesan apa pun",
+		MsgNoExpiry:                   "Tanpa batas waktu",
+		MsgExpiryTomorrow:             "Besok {0}",
+		MsgExpirySoon:                 "{0} dalam 5 menit",
+		MsgReminder:                   "PENGINGAT",
+		MsgConsolidated:               "Rekap",
+		MsgSelectDeleteOrder:          "Pilih pesanan yang akan dihapus",
+		MsgCancel:                     "Batal",
+		MsgInvalidItem:                "Pesanan tidak valid",
+		MsgDeletedOrder:               "Dihapus {0} {1}",
+		MsgCanceledDeleteOrderRequest: "Permintaan hapus pesanan dibatalkan",
+		MsgCancelOrder:                "Batalkan pesananmu dengan /cancelorder",
+		MsgLog:                        "Lihat siapa yang mengubah pesanan dengan /log",
+		MsgNoOrderEvents:              "Belum ada perubahan untuk pesanan ini",
+		MsgBot:                        "Bot",
+		MsgEventCreate:                "{0} {1} mulai menerima pesanan",
+		MsgEventAddItem:               "{0} {1} memesan {2} x {3}",
+		MsgEventChangeQuantity:        "{0} {1} menambah {2} x {3}",
+		MsgEventDeleteItem:            "{0} {1} menghapus {2} x {3}",
+		MsgEventClose:                 "{0} {1} berhenti menerima pesanan",
+		MsgEventExpire:                "{0} Pesanan berakhir",
+		MsgAPIKey:                     idAPIKey,
+		MsgAPIKeyInvalidFormat:        "Format tidak valid! " + idAPIKey,
+		MsgAPIKeyAdminOnly:            "Hanya admin grup yang bisa mengelola kunci API",
+		MsgAPIKeyCreated:              "Kunci API {0} dibuat, rahasiakan karena tidak akan ditampilkan lagi:\n\n{1}",
+		MsgAPIKeySent:                 "Kunci API dibuat dan dikirim kepadamu secara pribadi",
+		MsgAPIKeyStartPrivateChat:     "Tidak bisa mengirim kunci API, mulai obrolan pribadi dengan saya dulu lalu coba lagi",
+		MsgAPIKeyNotFound:             "Kunci API tidak ditemukan",
+		MsgAPIKeyRevoked:              "Kunci API {0} dicabut",
+		MsgAPIKeys:                    "Kunci API",
+		MsgAPIKeyListItem:             "{0} - dibuat {1} oleh {2}",
+		MsgNoAPIKeys:                  "Tidak ada kunci API aktif. Buat dengan /apikey create",
+		MsgLanguage:                   "Ganti bahasa bot dengan /language",
+		MsgLanguageName:               "Bahasa Indonesia",
+		MsgLanguageCurrent:            "Pesan di obrolan ini memakai {0}",
+		MsgLanguageCurrentAuto:        "Pesan di obrolan ini memakai bahasa Telegram setiap pengguna",
+		MsgLanguageSet:                "Pesan di obrolan ini sekarang memakai {0}",
+		MsgLanguageSetAuto:            "Pesan di obrolan ini sekarang memakai bahasa Telegram setiap pengguna",
+		MsgLanguageUsage:              idLanguage,
+		MsgLanguageUnsupported:        "Bahasa tidak didukung! " + idLanguage,
+		MsgLanguageAdminOnly:          "Hanya admin grup yang bisa mengganti bahasa",
+	},
+	Plurals: map[string]map[locales.PluralRule]string{
+		MsgItemCount: {
+			locales.PluralRuleOther: "{0} pesanan",
+		},
+	},
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/ms"
+	"github.com/gpng/order-bot/services/i18n"
+)
+
+const (
+	msTakeOrders = "Mula ambil pesanan dengan /takeorders 15:00 Kedai Mamak"
+	msEndOrders  = "Guna /endorders untuk berhenti ambil pesanan"
+	msOrder      = "Tambah pesanan dengan /order 2 teh tarik kurang manis"
+	msAPIKey     = "Urus kunci API dengan /apikey create, /apikey list atau /apikey revoke 1"
+	msLanguage   = "Guna /language diikuti {0}, atau /language auto untuk guna bahasa Telegram setiap pengguna"
+)
+
+var messagesMS = i18n.Catalog{
+	Locale: ms.New(),
+	Messages: map[string]string{
+		MsgError:                      "Alamak, ada yang tidak kena",
+		MsgIntro:                      "Guna HelpMeBuyLehBot untuk kumpul pesanan kumpulan!",
+		MsgHelp:                       "Arahan HelpMeBuyLehBot, argumen pilihan dalam kurungan:",
+		MsgHelpStart:                  "Tunjuk cara kumpul pesanan kumpulan",
+		MsgHelpHelp:                   "Senarai semua arahan",
+		MsgHelpTakeOrders:             "Mula ambil pesanan, boleh tamat pada masa seperti 15:00",
+		MsgHelpOrder:                  "Tambah pesanan pada pesanan aktif",
+		MsgHelpCheckOrder:             "Tunjuk pesanan aktif",
+		MsgHelpCancelOrder:            "Padam pesanan anda",
+		MsgHelpEndOrders:              "Berhenti ambil pesanan",
+		MsgHelpLog:                    "Tunjuk siapa ubah pesanan aktif",
+		MsgHelpLanguage:               "Tunjuk atau tukar bahasa sembang",
+		MsgHelpAPIKey:                 "Cipta, senarai atau batalkan kunci API sembang",
+		MsgCommandMissingArg:          "Tiada {0}! Guna {1}",
+		MsgCommandInvalidArg:          "{0} tidak sah: {1}! Guna {2}",
+		MsgCommandInvalidOption:       "{0}={1} tidak sah! Guna {2}",
+		MsgCommandUnterminatedQuote:   "Tiada tanda petikan penutup! Guna {0}",
+		MsgTakeOrders:                 msTakeOrders,
+		MsgEndTakeOrders:              msEndOrders,
+		MsgOrder:                      msOrder,
+		MsgNewTakeOrderExistingOrder:  "Pesanan untuk {0} sudah dibuka. " + msEndOrders,
+		MsgTakingOrders:               "Mengambil pesanan untuk {0}",
+		MsgTakingOrdersUntil:          "Mengambil pesanan untuk {0}, tamat pada {1}",
+		MsgTakingOrdersUntilTomorrow:  "Mengambil pesanan untuk {0}, tamat pada {1} esok",
+		MsgCancelTakeOrders:           "Berhenti mengambil pesanan",
+		MsgNoActiveOrders:             "Tiada pesanan aktif! " + msTakeOrders,
+		MsgOrderInvalidQuantity:       "Kuantiti tidak sah! " + msOrder,
+		MsgNoOrders:                   "Anda tiada pesanan sekarang",
+		MsgNoExpiry:                   "Tiada masa tamat",
+		MsgExpiryTomorrow:             "{0} esok",
+		MsgExpirySoon:                 "{0} dalam 5 minit",
+		MsgReminder:                   "PERINGATAN",
+		MsgConsolidated:               "Ringkasan",
+		MsgSelectDeleteOrder:          "Pilih pesanan untuk dipadam",
+		MsgCancel:                     "Batal",
+		MsgInvalidItem:                "Pesanan tidak sah",
+		MsgDeletedOrder:               "Dipadam {0} {1}",
+		MsgCanceledDeleteOrderRequest: "Permintaan padam pesanan dibatalkan",
+		MsgCancelOrder:                "Batalkan pesanan anda dengan /cancelorder",
+		MsgLog:                        "Lihat siapa ubah pesanan dengan /log",
+		MsgNoOrderEvents:              "Tiada perubahan direkodkan untuk pesanan ini",
+		MsgBot:                        "Bot",
+		MsgEventCreate:                "{0} {1} mula ambil pesanan",
+		MsgEventAddItem:               "{0} {1} memesan {2} x {3}",
+		MsgEventChangeQuantity:        "{0} {1} memesan {2} lagi x {3}",
+		MsgEventDeleteItem:            "{0} {1} memadam {2} x {3}",
+		MsgEventClose:                 "{0} {1} berhenti ambil pesanan",
+		MsgEventExpire:                "{0} Pesanan tamat",
+		MsgAPIKey:                     msAPIKey,
+		MsgAPIKeyInvalidFormat:        "Format tidak sah! " + msAPIKey,
+		MsgAPIKeyAdminOnly:            "Hanya admin kumpulan boleh urus kunci API",
+		MsgAPIKeyCreated:              "Kunci API {0} dicipta, rahsiakan kerana ia tidak akan ditunjukkan lagi:\n\n{1}",
+		MsgAPIKeySent:                 "Kunci API dicipta dan dihantar kepada anda secara peribadi",
+		MsgAPIKeyStartPrivateChat:     "Tidak dapat hantar kunci API, mulakan sembang peribadi dengan saya dahulu dan cuba lagi",
+		MsgAPIKeyNotFound:             "Kunci API tidak dijumpai",
+		MsgAPIKeyRevoked:              "Kunci API {0} dibatalkan",
+		MsgAPIKeys:                    "Kunci API",
+		MsgAPIKeyListItem:             "{0} - dicipta {1} oleh {2}",
+		MsgNoAPIKeys:                  "Tiada kunci API aktif. Cipta satu dengan /apikey create",
+		MsgLanguage:                   "Tukar bahasa bot dengan /language",
+		MsgLanguageName:               "Bahasa Melayu",
+		MsgLanguageCurrent:            "Mesej dalam sembang ini dalam {0}",
+		MsgLanguageCurrentAuto:        "Mesej dalam sembang ini dalam bahasa Telegram setiap pengguna",
+		MsgLanguageSet:                "Mesej dalam sembang ini kini dalam {0}",
+		MsgLanguageSetAuto:            "Mesej dalam sembang ini kini dalam bahasa Telegram setiap pengguna",
+		MsgLanguageUsage:              msLanguage,
+		MsgLanguageUnsupported:        "Bahasa tidak disokong! " + msLanguage,
+		MsgLanguageAdminOnly:          "Hanya admin kumpulan boleh tukar bahasa",
+	},
+	Plurals: map[string]map[locales.PluralRule]string{
+		MsgItemCount: {
+			locales.PluralRuleOther: "{0} pesanan",
+		},
+	},
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/zh"
+	"github.com/gpng/order-bot/services/i18n"
+)
+
+const (
+	zhTakeOrders = "使用 /takeorders 15:00 奶茶店 开始接单"
+	zhEndOrders  = "使用 /endorders 停止接单"
+	zhOrder      = "使用 /order 2 珍珠奶茶少糖 点单"
+	zhAPIKey     = "使用 /apikey create、/apikey list 或 /apikey revoke 1 管理 API 密钥"
+	zhLanguage   = "使用 /language 加上 {0} 切换语言，或使用 /language auto 跟随每位用户的 Telegram 语言"
+)
+
+var messagesZH = i18n.Catalog{
+	Locale: zh.New(),
+	Messages: map[string]string{
+		MsgError:                      "哎呀，出错了",
+		MsgIntro:                      "使用 HelpMeBuyLehBot 收集群组订单！",
+		MsgHelp:                       "HelpMeBuyLehBot 的命令，方括号内为可选参数：",
+		MsgHelpStart:                  "查看如何收集群组订单",
+		MsgHelpHelp:                   "列出所有命令",
+		MsgHelpTakeOrders:             "开始接单，可设截止时间如 15:00",
+		MsgHelpOrder:                  "在进行中的订单里点单",
+		MsgHelpCheckOrder:             "查看进行中的订单",
+		MsgHelpCancelOrder:            "删除你的点单",
+		MsgHelpEndOrders:              "停止接单",
+		MsgHelpLog:                    "查看谁修改了进行中的订单",
+		MsgHelpLanguage:               "查看或切换聊天的语言",
+		MsgHelpAPIKey:                 "创建、列出或撤销聊天的 API 密钥",
+		MsgCommandMissingArg:          "缺少 {0}！请使用 {1}",
+		MsgCommandInvalidArg:          "{0} 无效：{1}！请使用 {2}",
+		MsgCommandInvalidOption:       "{0}={1} 无效！请使用 {2}",
+		MsgCommandUnterminatedQuote:   "缺少结束引号！请使用 {0}",
+		MsgTakeOrders:                 zhTakeOrders,
+		MsgEndTakeOrders:              zhEndOrders,
+		MsgOrder:                      zhOrder,
+		MsgNewTakeOrderExistingOrder:  "{0} 的订单已在进行中。" + zhEndOrders,
+		MsgTakingOrders:               "开始接单：{0}",
+		MsgTakingOrdersUntil:          "开始接单：{0}，{1} 截止",
+		MsgTakingOrdersUntilTomorrow:  "开始接单：{0}，明天 {1} 截止",
+		MsgCancelTakeOrders:           "已停止接单",
+		MsgNoActiveOrders:             "没有进行中的订单！" + zhTakeOrders,
+		MsgOrderInvalidQuantity:       "数量无效！" + zhOrder,
+		MsgNoOrders:                   "你目前没有点单",
+		MsgNoExpiry:                   "没有截止时间",
+		MsgExpiryTomorrow:             "明天 {0}",
+		MsgExpirySoon:                 "{0}，5 分钟后截止",
+		MsgReminder:                   "提醒",
+		MsgConsolidated:               "汇总",
+		MsgSelectDeleteOrder:          "选择要删除的点单",
+		MsgCancel:                     "取消",
+		MsgInvalidItem:                "无效的点单",
+		MsgDeletedOrder:               "已删除 {0} {1}",
+		MsgCanceledDeleteOrderRequest: "已取消删除点单",
+		MsgCancelOrder:                "使用 /cancelorder 取消你的点单",
+		MsgLog:                        "使用 /log 查看谁修改了订单",
+		MsgNoOrderEvents:              "这个订单没有修改记录",
+		MsgBot:                        "机器人",
+		MsgEventCreate:                "{0} {1} 开始接单",
+		MsgEventAddItem:               "{0} {1} 点了 {2} x {3}",
+		MsgEventChangeQuantity:        "{0} {1} 加点了 {2} x {3}",
+		MsgEventDeleteItem:            "{0} {1} 删除了 {2} x {3}",
+		MsgEventClose:                 "{0} {1} 停止接单",
+		MsgEventExpire:                "{0} 订单已截止",
+		MsgAPIKey:                     zhAPIKey,
+		MsgAPIKeyInvalidFormat:        "格式无效！" + zhAPIKey,
+		MsgAPIKeyAdminOnly:            "只有群组管理员可以管理 API 密钥",
+		MsgAPIKeyCreated:              "API 密钥 {0} 已创建，请妥善保管，它不会再次显示：\n\n{1}",
+		MsgAPIKeySent:                 "API 密钥已创建并私信发送给你",
+		MsgAPIKeyStartPrivateChat:     "无法发送 API 密钥，请先和我开始私聊后再试",
+		MsgAPIKeyNotFound:             "找不到 API 密钥",
+		MsgAPIKeyRevoked:              "已撤销 API 密钥 {0}",
+		MsgAPIKeys:                    "API 密钥",
+		MsgAPIKeyListItem:             "{0} - {1} 由 {2} 创建",
+		MsgNoAPIKeys:                  "没有有效的 API 密钥。使用 /apikey create 创建",
+		MsgLanguage:                   "使用 /language 切换机器人的语言",
+		MsgLanguageName:               "中文",
+		MsgLanguageCurrent:            "这个聊天的消息使用{0}",
+		MsgLanguageCurrentAuto:        "这个聊天的消息使用每位用户的 Telegram 语言",
+		MsgLanguageSet:                "这个聊天的消息现在使用{0}",
+		MsgLanguageSetAuto:            "这个聊天的消息现在使用每位用户的 Telegram 语言",
+		MsgLanguageUsage:              zhLanguage,
+		MsgLanguageUnsupported:        "不支持的语言！" + zhLanguage,
+		MsgLanguageAdminOnly:          "只有群组管理员可以切换语言",
+	},
+	Plurals: map[string]map[locales.PluralRule]string{
+		MsgItemCount: {
+			locales.PluralRuleOther: "{0} 份",
+		},
+	},
+}
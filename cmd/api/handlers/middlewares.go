@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/gpng/order-bot/services/logger"
+	"github.com/gpng/order-bot/services/telegram"
+	"go.uber.org/zap"
+)
+
+// context keys
+const (
+	ContextKeyChatID ContextKey = "chat_id"
+)
+
+// requestLogger puts a logger with the request id into the request context, see logger
+func (h *Handlers) requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := h.Logger.With(zap.String("request_id", middleware.GetReqID(r.Context())))
+		next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context(), l)))
+	})
+}
+
+// logger of the request, update or job in ctx, falling back to the handlers' logger
+func (h *Handlers) logger(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, h.Logger)
+}
+
+// verifyWebhookSecret rejects webhook requests without the secret token set through setWebhook
+func (h *Handlers) verifyWebhookSecret(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(telegram.SecretTokenHeader)
+		if h.WebhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.WebhookSecret)) != 1 {
+			respondWithStatus(w, http.StatusUnauthorized, errorMessage(http.StatusUnauthorized, "Invalid secret token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIKeyAuth is chi middleware that authenticates requests by api key and scopes them to the key's chat,
+// see ChatIDFromContext. Revoked keys are rejected.
+func (h *Handlers) APIKeyAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := apiKeyFromRequest(r)
+		if key == "" {
+			respondWithStatus(w, http.StatusUnauthorized, errorMessage(http.StatusUnauthorized, "Missing API key"))
+			return
+		}
+
+		apiKey, err := h.Repo.GetAPIKeyByHash(r.Context(), hashAPIKey(key))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithStatus(w, http.StatusUnauthorized, errorMessage(http.StatusUnauthorized, "Invalid API key"))
+				return
+			}
+			h.logger(r.Context()).Error("error fetching api key", zap.Error(err))
+			respondWithStatus(w, http.StatusInternalServerError, errorMessage(http.StatusInternalServerError, h.t(r.Context()).T(MsgError)))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ContextKeyChatID, apiKey.ChatID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// apiKeyFromRequest reads the key from a bearer Authorization header or the X-API-Key header
+func apiKeyFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// ChatIDFromContext returns the chat scope injected by APIKeyAuth
+func ChatIDFromContext(ctx context.Context) (int64, bool) {
+	chatID, ok := ctx.Value(ContextKeyChatID).(int64)
+	return chatID, ok
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gpng/order-bot/sqlc/models"
+	"github.com/gpng/order-bot/sqlc/models/memory"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	repo := memory.New()
+	h := &Handlers{Logger: zaptest.NewLogger(t), Repo: repo}
+	ctx := context.Background()
+
+	const chatID = -1001
+	if _, err := repo.CreateAPIKey(ctx, models.CreateAPIKeyParams{ChatID: chatID, CreatedBy: 1001, KeyHash: hashAPIKey("ob_valid")}); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	revoked, err := repo.CreateAPIKey(ctx, models.CreateAPIKeyParams{ChatID: chatID, CreatedBy: 1001, KeyHash: hashAPIKey("ob_revoked")})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if _, err := repo.RevokeAPIKey(ctx, models.RevokeAPIKeyParams{ID: revoked.ID, ChatID: chatID}); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"bearer token", "Authorization", "Bearer ob_valid", http.StatusOK},
+		{"api key header", "X-API-Key", "ob_valid", http.StatusOK},
+		{"missing key", "", "", http.StatusUnauthorized},
+		{"other scheme", "Authorization", "Basic ob_valid", http.StatusUnauthorized},
+		{"unknown key", "Authorization", "Bearer ob_unknown", http.StatusUnauthorized},
+		{"revoked key", "Authorization", "Bearer ob_revoked", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotChatID int64
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotChatID, _ = ChatIDFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			h.APIKeyAuth(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("responded with %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && gotChatID != chatID {
+				t.Fatalf("chat scope = %d, want %d", gotChatID, chatID)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gpng/order-bot/services/logger"
+	"github.com/gpng/order-bot/services/telegram"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+const (
+	pollTimeoutSeconds = 30
+	pollRetryInterval  = 5 * time.Second
+)
+
+// PollUpdates long polls telegram with getUpdates until ctx is done, as an alternative to the webhook
+func (h *Handlers) PollUpdates(ctx context.Context, bot *telegram.Bot) {
+	l := h.Logger.With(zap.String("transport", "polling"))
+
+	offset := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		raw, err := getUpdates(ctx, bot, offset)
+		if ctx.Err() != nil {
+			return
+		}
+		var updates []models.TelegramUpdate
+		if err == nil {
+			err = json.Unmarshal(raw, &updates)
+		}
+		if err != nil {
+			l.Error("failed to get updates", zap.Error(err))
+		} else if offset, err = h.queueUpdates(ctx, l, updates, offset); err == nil {
+			continue
+		} else if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollRetryInterval):
+		}
+	}
+}
+
+// queueUpdates submits updates to the update pool, returning the offset that acknowledges the queued ones.
+// Updates from the one that failed to queue are not acknowledged, so the next poll fetches them again.
+func (h *Handlers) queueUpdates(ctx context.Context, l *zap.Logger, updates []models.TelegramUpdate, offset int) (int, error) {
+	for i := range updates {
+		update := &updates[i]
+		// queued updates are drained after polling stops, so they don't inherit its cancellation
+		updateCtx := h.updateContext(logger.WithContext(context.Background(), l), update)
+		err := h.Updates.Submit(ctx, updateChatID(update), func() {
+			h.dispatchUpdate(updateCtx, update)
+		})
+		if err != nil {
+			h.logger(updateCtx).Error("failed to queue update", zap.Error(err))
+			return offset, err
+		}
+		// acknowledge updates by requesting from the next id onwards
+		offset = update.UpdateID + 1
+	}
+	return offset, nil
+}
+
+// getUpdates returns early when ctx is done, abandoning the long poll. Its updates are not lost,
+// as they are only acknowledged by the offset of the next poll.
+func getUpdates(ctx context.Context, bot *telegram.Bot, offset int) (json.RawMessage, error) {
+	type result struct {
+		raw json.RawMessage
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		raw, err := bot.GetUpdates(offset, pollTimeoutSeconds)
+		done <- result{raw, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.raw, r.err
+	}
+}
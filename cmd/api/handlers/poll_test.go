@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gpng/order-bot/services/workerpool"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestQueueUpdatesFailure(t *testing.T) {
+	pool := workerpool.New(1, 1, zaptest.NewLogger(t))
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	h := &Handlers{Logger: zaptest.NewLogger(t), Updates: pool}
+
+	updates := []models.TelegramUpdate{{UpdateID: 10}, {UpdateID: 11}}
+	offset, err := h.queueUpdates(context.Background(), h.Logger, updates, 10)
+	if !errors.Is(err, workerpool.ErrClosed) {
+		t.Fatalf("queueUpdates err = %v, want ErrClosed", err)
+	}
+	// the update is not acknowledged, so the next poll fetches it again
+	if offset != 10 {
+		t.Fatalf("offset = %d, want 10", offset)
+	}
+}
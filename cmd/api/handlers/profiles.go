@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+// syncProfiles upserts the chat and users of an update, so names shown are current
+func (h *Handlers) syncProfiles(ctx context.Context, update *models.TelegramUpdate) {
+	switch {
+	case update.Message != nil:
+		h.upsertChat(ctx, update.Message.Chat)
+		h.upsertUser(ctx, update.Message.From)
+		for _, member := range update.Message.NewChatMembers {
+			h.upsertUser(ctx, member)
+		}
+	case update.CallbackQuery != nil:
+		if update.CallbackQuery.Message != nil {
+			h.upsertChat(ctx, update.CallbackQuery.Message.Chat)
+		}
+		h.upsertUser(ctx, update.CallbackQuery.From)
+	}
+}
+
+func (h *Handlers) upsertChat(ctx context.Context, chat models.Chat) {
+	if chat.ID == 0 {
+		return
+	}
+	err := h.Repo.UpsertChat(ctx, models.UpsertChatParams{
+		ID:       chat.ID,
+		Type:     chat.Type,
+		Title:    chat.Title,
+		Username: chat.Username,
+	})
+	if err != nil {
+		h.logger(ctx).Error("error saving chat", zap.Error(err))
+	}
+}
+
+func (h *Handlers) upsertUser(ctx context.Context, user models.User) {
+	if user.ID == 0 {
+		return
+	}
+	err := h.Repo.UpsertUser(ctx, models.UpsertUserParams{
+		ID:           user.ID,
+		IsBot:        user.IsBot,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Username:     user.Username,
+		LanguageCode: user.LanguageCode,
+	})
+	if err != nil {
+		h.logger(ctx).Error("error saving user", zap.Int64("saved_user_id", user.ID), zap.Error(err))
+	}
+}
+
+// displayName is the full name, falling back to @username and then the id
+func displayName(userID int64, firstName string, lastName string, username string) string {
+	if name := strings.TrimSpace(firstName + " " + lastName); name != "" {
+		return name
+	}
+	if username != "" {
+		return "@" + username
+	}
+	return strconv.FormatInt(userID, 10)
+}
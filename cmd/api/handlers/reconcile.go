@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gpng/order-bot/services/logger"
+	"github.com/gpng/order-bot/services/scheduler"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+// orders are only expired by the reconciler reconcileGrace after expiry, to leave time for their job to run
+const reconcileGrace = time.Minute
+
+// ReconcileSummary counts the actions taken by Reconcile
+type ReconcileSummary struct {
+	Expired   int
+	Scheduled int
+	Relinked  int
+	Cancelled int
+	Failed    int
+}
+
+// orderJob identifies the reminder or expiry job of an order
+type orderJob struct {
+	orderID   int32
+	preExpiry bool
+}
+
+// RunReconciler reconciles straight away and then every interval until ctx is done
+func (h *Handlers) RunReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := h.Reconcile(ctx); err != nil {
+			h.logger(ctx).Error("failed to reconcile orders and jobs", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile repairs orders whose jobs were lost or missed, such as when redis was flushed or workers were down.
+// Overdue active orders are expired, missing jobs of active orders are scheduled again and jobs of inactive orders are cancelled.
+func (h *Handlers) Reconcile(ctx context.Context) (ReconcileSummary, error) {
+	l := h.logger(ctx).With(zap.String("task", "reconcile"))
+	var summary ReconcileSummary
+
+	scheduled, err := h.Scheduler.List(ctx)
+	if err != nil {
+		return summary, err
+	}
+	orders, err := h.Repo.GetActiveOrdersWithExpiry(ctx)
+	if err != nil {
+		return summary, err
+	}
+
+	jobs := map[orderJob][]scheduler.Scheduled{}
+	for _, s := range scheduled {
+		if JobName(s.Job.Name) != JobNotifyExpiry {
+			continue
+		}
+		key := orderJob{int32(s.Job.ArgInt64(jobArgOrderID)), s.Job.ArgBool(jobArgPreExpiry)}
+		jobs[key] = append(jobs[key], s)
+	}
+
+	now := h.now()
+	var (
+		overdue []models.Order
+		pending []models.Order
+		active  = map[int32]bool{}
+	)
+	for _, order := range orders {
+		if h.orderExpiry(order).Before(now.Add(-reconcileGrace)) {
+			overdue = append(overdue, order)
+			continue
+		}
+		active[order.ID] = true
+		pending = append(pending, order)
+	}
+
+	// cancel first so jobs of overdue orders don't notify again
+	for key, js := range jobs {
+		if active[key.orderID] {
+			continue
+		}
+		for _, s := range js {
+			jl := l.With(zap.Int32("order_id", key.orderID), zap.String("handle", string(s.Handle)))
+			if err := h.Scheduler.Cancel(ctx, s.Handle); err != nil {
+				jl.Error("failed to cancel orphaned job", zap.Error(err))
+				summary.Failed++
+				continue
+			}
+			jl.Info("cancelled orphaned job", zap.Time("run_at", s.At))
+			summary.Cancelled++
+		}
+	}
+
+	for _, order := range overdue {
+		ol := l.With(zap.Int32("order_id", order.ID), zap.Int64("chat_id", order.ChatID))
+		expired, err := h.expireOverdueOrder(ctx, ol, order)
+		if err != nil {
+			ol.Error("failed to expire overdue order", zap.Error(err))
+			summary.Failed++
+			continue
+		}
+		if expired {
+			ol.Info("expired overdue order", zap.Time("expiry", h.orderExpiry(order)))
+			summary.Expired++
+		}
+	}
+
+	for _, order := range pending {
+		ol := l.With(zap.Int32("order_id", order.ID), zap.Int64("chat_id", order.ChatID))
+		expiry := h.orderExpiry(order)
+		// only reminders the order had are restored, as orders close to expiry never get one
+		if order.ReminderID.Valid {
+			h.reconcileOrderJob(ctx, ol, &summary, order, orderJob{order.ID, true}, order.ReminderID.String, expiry.Add(-reminderBefore), now, jobs)
+		}
+		h.reconcileOrderJob(ctx, ol, &summary, order, orderJob{order.ID, false}, order.ExpiryID.String, expiry, now, jobs)
+	}
+
+	l.Info("reconciled orders and jobs",
+		zap.Int("expired", summary.Expired),
+		zap.Int("scheduled", summary.Scheduled),
+		zap.Int("relinked", summary.Relinked),
+		zap.Int("cancelled", summary.Cancelled),
+		zap.Int("failed", summary.Failed),
+	)
+	return summary, nil
+}
+
+// reconcileOrderJob makes the order's handle point at its scheduled job, scheduling the job again if it is missing
+func (h *Handlers) reconcileOrderJob(
+	ctx context.Context,
+	l *zap.Logger,
+	summary *ReconcileSummary,
+	order models.Order,
+	key orderJob,
+	handle string,
+	at time.Time,
+	now time.Time,
+	jobs map[orderJob][]scheduler.Scheduled,
+) {
+	l = l.With(zap.Bool("pre_expiry", key.preExpiry))
+
+	if js := jobs[key]; len(js) > 0 {
+		linked := js[0]
+		for _, s := range js {
+			if string(s.Handle) == handle {
+				linked = s
+			}
+		}
+		for _, s := range js {
+			if s.Handle == linked.Handle {
+				continue
+			}
+			if err := h.Scheduler.Cancel(ctx, s.Handle); err != nil {
+				l.Error("failed to cancel duplicate job", zap.String("handle", string(s.Handle)), zap.Error(err))
+				summary.Failed++
+				continue
+			}
+			l.Info("cancelled duplicate job", zap.String("handle", string(s.Handle)))
+			summary.Cancelled++
+		}
+		if string(linked.Handle) == handle {
+			return
+		}
+
+		// the job exists but the order lost track of it, e.g. after a failed save
+		if err := saveOrderJob(ctx, h.Repo, order.ID, key.preExpiry, linked.At, linked.Handle); err != nil {
+			l.Error("failed to relink job", zap.Error(err))
+			summary.Failed++
+			return
+		}
+		l.Info("relinked job", zap.String("handle", string(linked.Handle)))
+		summary.Relinked++
+		return
+	}
+
+	// jobs that are due have left the scheduled set to run
+	if !at.After(now) {
+		return
+	}
+	if err := h.scheduleOrderJob(ctx, h.Repo, order, key.preExpiry, at); err != nil {
+		l.Error("failed to schedule missing job", zap.Error(err))
+		summary.Failed++
+		return
+	}
+	l.Info("scheduled missing job", zap.Time("run_at", at))
+	summary.Scheduled++
+}
+
+// expireOverdueOrder ends the order like its expiry job would have, returning false if it was already ended
+func (h *Handlers) expireOverdueOrder(ctx context.Context, l *zap.Logger, order models.Order) (bool, error) {
+	ctx = h.withLanguage(logger.WithContext(ctx, l), order.ChatID, order.LanguageCode)
+
+	// CloseOrder only returns the order if it deactivated it, so concurrent reconcilers expire each order once,
+	// and an order ended since it was listed is skipped rather than closing the chat's next order
+	_, err := h.closeOrder(ctx, order.ID, models.OrderEventExpire, 0, 0)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := h.sendOverview(ctx, order, false); err != nil {
+		return true, err
+	}
+	return true, h.sendMessage(ctx, order.ChatID, false, h.t(ctx).T(MsgCancelTakeOrders))
+}
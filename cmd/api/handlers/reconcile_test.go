@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/cmd/api/handlers"
+	"github.com/gpng/order-bot/cmd/api/handlers/handlerstest"
+)
+
+// flushJobs cancels every scheduled job, like redis being flushed
+func flushJobs(t *testing.T, h *handlerstest.Harness) {
+	t.Helper()
+	ctx := context.Background()
+	scheduled, err := h.Scheduler.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range scheduled {
+		if err := h.Scheduler.Cancel(ctx, s.Handle); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func reconcile(t *testing.T, h *handlerstest.Harness, want handlers.ReconcileSummary) {
+	t.Helper()
+	summary, err := h.Handlers.Reconcile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != want {
+		t.Fatalf("expected summary %+v, got %+v", want, summary)
+	}
+}
+
+func TestReconcileExpiresOverdueOrders(t *testing.T) {
+	h := handlerstest.New(t)
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Clock.Set(time.Date(2021, 3, 1, 12, 0, 0, 0, location))
+
+	h.SendText(group, alice, "/takeorders 12:30 Coffeeshop")
+	h.SendText(group, alice, "/order kopi")
+	flushJobs(t, h)
+
+	h.Clock.Advance(time.Hour)
+	reconcile(t, h, handlers.ReconcileSummary{Expired: 1})
+	h.ExpectLastSent(group.ID, en(handlers.MsgCancelTakeOrders))
+
+	h.SendText(group, bob, "/order teh")
+	h.ExpectLastSent(group.ID, en(handlers.MsgNoActiveOrders))
+
+	reconcile(t, h, handlers.ReconcileSummary{})
+}
+
+func TestReconcileSchedulesMissingJobs(t *testing.T) {
+	h := handlerstest.New(t)
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Clock.Set(time.Date(2021, 3, 1, 12, 0, 0, 0, location))
+
+	h.SendText(group, alice, "/takeorders 12:30 Coffeeshop")
+	flushJobs(t, h)
+
+	reconcile(t, h, handlers.ReconcileSummary{Scheduled: 2})
+	reconcile(t, h, handlers.ReconcileSummary{})
+
+	h.Advance(26 * time.Minute)
+	h.ExpectLastSent(group.ID, "REMINDER", "12:30 in 5 minutes")
+	h.Advance(5 * time.Minute)
+	h.ExpectLastSent(group.ID, en(handlers.MsgCancelTakeOrders))
+}
+
+func TestReconcileCancelsOrphanedJobs(t *testing.T) {
+	h := handlerstest.New(t)
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Clock.Set(time.Date(2021, 3, 1, 12, 0, 0, 0, location))
+
+	h.SendText(group, alice, "/takeorders 12:30 Coffeeshop")
+	order, err := h.Repo.GetActiveOrder(context.Background(), group.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Repo.DeactivateOrder(context.Background(), order.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcile(t, h, handlers.ReconcileSummary{Cancelled: 2})
+	if n := h.Scheduler.Pending(); n != 0 {
+		t.Fatalf("expected orphaned jobs to be cancelled, got %d", n)
+	}
+}
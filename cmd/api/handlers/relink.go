@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+// RelinkSummary counts the ids repaired by RelinkWrappedIDs
+type RelinkSummary struct {
+	Chats   int
+	Users   int
+	Skipped int
+}
+
+// RelinkWrappedIDs moves orders and items that older versions stored under ids truncated to 32 bits to the full ids
+// of the chats and users seen since, see api migrate relink-ids. It can be run again as more chats and users are seen.
+// Truncated ids that can't be relinked safely are logged and skipped, such as when several full ids truncate to the same
+// id, or when the truncated id is also the id of a chat or user that has been seen.
+func RelinkWrappedIDs(ctx context.Context, repo models.Store, l *zap.Logger) (RelinkSummary, error) {
+	var summary RelinkSummary
+
+	chatIDs, err := repo.GetLargeChatIDs(ctx)
+	if err != nil {
+		return summary, err
+	}
+	for _, wrapped := range byWrappedID(chatIDs) {
+		l := l.With(zap.Int64("wrapped_chat_id", wrapped.id), zap.Int64s("chat_ids", wrapped.fullIDs))
+		err := repo.ExecTx(ctx, func(q models.Querier) error {
+			return relinkWrappedChatID(ctx, q, l, wrapped, &summary)
+		})
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	userIDs, err := repo.GetLargeUserIDs(ctx)
+	if err != nil {
+		return summary, err
+	}
+	for _, wrapped := range byWrappedID(userIDs) {
+		l := l.With(zap.Int64("wrapped_user_id", wrapped.id), zap.Int64s("user_ids", wrapped.fullIDs))
+		err := repo.ExecTx(ctx, func(q models.Querier) error {
+			return relinkWrappedUserID(ctx, q, l, wrapped, &summary)
+		})
+		if err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+// wrappedID is an id truncated to 32 bits, and the full ids truncating to it
+type wrappedID struct {
+	id      int64
+	fullIDs []int64
+}
+
+func byWrappedID(ids []int64) []wrappedID {
+	byID := map[int64][]int64{}
+	for _, id := range ids {
+		byID[int64(int32(id))] = append(byID[int64(int32(id))], id)
+	}
+	wrapped := make([]wrappedID, 0, len(byID))
+	for id, fullIDs := range byID {
+		wrapped = append(wrapped, wrappedID{id, fullIDs})
+	}
+	sort.Slice(wrapped, func(i, j int) bool { return wrapped[i].id < wrapped[j].id })
+	return wrapped
+}
+
+// relinkWrappedChatID moves the orders of the wrapped chat id like a chat upgraded to a supergroup.
+// An active order is left behind if the chat already has one, to be closed with api orders close.
+func relinkWrappedChatID(ctx context.Context, q models.Querier, l *zap.Logger, wrapped wrappedID, summary *RelinkSummary) error {
+	orders, err := q.GetOrdersByChatID(ctx, wrapped.id)
+	if err != nil || len(orders) == 0 {
+		return err
+	}
+	if len(wrapped.fullIDs) > 1 {
+		l.Warn("skipping wrapped chat id of several chats")
+		summary.Skipped++
+		return nil
+	}
+	// chats of older versions were created by migration without a type, so a typed chat has been seen with this id
+	chat, err := q.GetChat(ctx, wrapped.id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if chat.Type != "" {
+		l.Warn("skipping wrapped chat id of a real chat", zap.String("chat_type", chat.Type))
+		summary.Skipped++
+		return nil
+	}
+
+	err = q.MigrateChatID(ctx, models.MigrateChatIDParams{
+		ChatID:    wrapped.fullIDs[0],
+		OldChatID: wrapped.id,
+	})
+	if err != nil {
+		return err
+	}
+	summary.Chats++
+	l.Info("relinked wrapped chat id", zap.Int("orders", len(orders)))
+
+	left, err := q.GetActiveOrder(ctx, wrapped.id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	l.Warn("left active order of wrapped chat id as the chat has one", zap.Int32("order_id", left.ID))
+	summary.Skipped++
+	return nil
+}
+
+// relinkWrappedUserID moves the items of the wrapped user id, merging those the user ordered again since
+func relinkWrappedUserID(ctx context.Context, q models.Querier, l *zap.Logger, wrapped wrappedID, summary *RelinkSummary) error {
+	// items reference users, so there are none without the user
+	user, err := q.GetUser(ctx, wrapped.id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(wrapped.fullIDs) > 1 {
+		l.Warn("skipping wrapped user id of several users")
+		summary.Skipped++
+		return nil
+	}
+	// user ids are positive, and users of older versions were created by migration with only a first name
+	if wrapped.id > 0 {
+		chat, err := q.GetChat(ctx, wrapped.id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if chat.Type != "" || user.Username != "" || user.LastName != "" || user.LanguageCode != "" {
+			l.Warn("skipping wrapped user id of a real user")
+			summary.Skipped++
+			return nil
+		}
+	}
+
+	moved, err := q.RelinkWrappedUserID(ctx, models.RelinkWrappedUserIDParams{
+		UserID:        wrapped.fullIDs[0],
+		WrappedUserID: wrapped.id,
+	})
+	if err != nil || moved == 0 {
+		return err
+	}
+	summary.Users++
+	l.Info("relinked wrapped user id", zap.Int64("items", moved))
+	return nil
+}
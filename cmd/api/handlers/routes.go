@@ -7,12 +7,33 @@ import (
 // Routes for app
 func (h *Handlers) Routes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(h.requestLogger)
 
-	router.Get("/", h.handleStatus())
+	router.Mount("/", h.HealthRoutes())
 
-	router.Route("/" + h.BotToken, func (r chi.Router) {
+	router.Route(h.WebhookPath, func(r chi.Router) {
+		r.Use(h.verifyWebhookSecret)
 		r.Post("/", h.handleUpdates())
 	})
 
+	// http api, scoped to a single chat by api key
+	router.Mount("/api", h.APIKeyAuth(h.APIRoutes()))
+
+	return router
+}
+
+// APIRoutes of the http api, served behind APIKeyAuth so handlers can read the key's chat with ChatIDFromContext
+func (h *Handlers) APIRoutes() chi.Router {
+	return chi.NewRouter()
+}
+
+// HealthRoutes for status, liveness and readiness, also served by workers
+func (h *Handlers) HealthRoutes() chi.Router {
+	router := chi.NewRouter()
+
+	router.Get("/", h.handleStatus())
+	router.Get("/healthz", h.handleHealthz())
+	router.Get("/readyz", h.handleReadyz())
+
 	return router
 }
@@ -1,36 +1,58 @@
 package handlers
 
 import (
-	"github.com/gocraft/work"
+	"time"
+
+	"github.com/gpng/order-bot/services/clock"
+	"github.com/gpng/order-bot/services/dedup"
+	"github.com/gpng/order-bot/services/health"
+	"github.com/gpng/order-bot/services/scheduler"
 	"github.com/gpng/order-bot/services/telegram"
+	"github.com/gpng/order-bot/services/workerpool"
 	"github.com/gpng/order-bot/sqlc/models"
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
 // Handlers struct
 type Handlers struct {
-	BotToken   string
-	Logger     *zap.Logger
-	DB         models.DBTX
-	Repo       models.Querier
-	Bot        *telegram.Bot
-	Queue      *work.Enqueuer
-	WorkClient *work.Client
+	BotToken      string
+	WebhookPath   string
+	WebhookSecret string
+	Location      *time.Location
+	Logger        *zap.Logger
+	DB            models.DBTX
+	Repo          models.Store
+	Bot           telegram.Messenger
+	Scheduler     scheduler.Scheduler
+	Clock         clock.Clock
+	Dedup         dedup.Deduplicator
+	Updates       *workerpool.Pool
+	Health        *health.Checker
 }
 
 // New service
 func New(
 	botToken string,
+	webhookPath string,
+	webhookSecret string,
+	location *time.Location,
 	logger *zap.Logger,
 	db models.DBTX,
-	repo models.Querier,
-	bot *telegram.Bot,
-	queue *work.Enqueuer,
-	workClient *work.Client,
+	repo models.Store,
+	bot telegram.Messenger,
+	scheduler scheduler.Scheduler,
+	clock clock.Clock,
+	dedup dedup.Deduplicator,
+	updates *workerpool.Pool,
+	health *health.Checker,
 ) *Handlers {
-	return &Handlers{botToken, logger, db, repo, bot, queue, workClient}
+	return &Handlers{botToken, webhookPath, webhookSecret, location, logger, db, repo, bot, scheduler, clock, dedup, updates, health}
 }
 
+// tracer of update and job spans, a no-op unless tracing is set up
+var tracer = otel.Tracer("github.com/gpng/order-bot/cmd/api/handlers")
+
 // JobName are job names
 type JobName string
 
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gpng/order-bot/sqlc/models"
+)
+
+// now is the current time in the bot's location
+func (h *Handlers) now() time.Time {
+	return h.Clock.Now().In(h.Location)
+}
+
+// nextExpiry returns the next hour:min wall clock time after now in now's location, and whether it is tomorrow.
+// Days are stepped by date rather than 24 hours so the wall clock time is kept across DST changes.
+func nextExpiry(now time.Time, hour int, min int) (time.Time, bool) {
+	expiry := wallClock(now.Year(), now.Month(), now.Day(), hour, min, now.Location())
+	if !expiry.Before(now) {
+		return expiry, false
+	}
+	return wallClock(now.Year(), now.Month(), now.Day()+1, hour, min, now.Location()), true
+}
+
+// wallClock is time.Date, except times skipped by a DST change are moved forward by the size of the gap,
+// so 02:30 on the day clocks go from 02:00 to 03:00 is 03:30
+func wallClock(year int, month time.Month, day int, hour int, min int, location *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, 0, 0, location)
+	if t.Hour() == hour && t.Minute() == min {
+		return t
+	}
+	// time.Date picks either side of the gap depending on the zone, such as London and Sydney where it is already moved forward
+	want := time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if got.After(want) {
+		return t
+	}
+	_, before := t.Add(-12 * time.Hour).Zone()
+	_, after := t.Add(12 * time.Hour).Zone()
+	return t.Add(time.Duration(after-before) * time.Second)
+}
+
+// isAfterToday reports whether expiry's wall clock date is after now's date.
+// Expiry is compared by wall clock as TIMESTAMP columns drop the time zone.
+func isAfterToday(now time.Time, expiry time.Time) bool {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(expiry.Year(), expiry.Month(), expiry.Day(), 0, 0, 0, 0, time.UTC)
+	return day.After(today)
+}
+
+// orderExpiry is the order's expiry in the bot's location
+func (h *Handlers) orderExpiry(order models.Order) time.Time {
+	return h.timestamp(order.Expiry.Time)
+}
+
+// timestamp read from a TIMESTAMP column in the bot's location.
+// TIMESTAMP columns hold the wall clock in the bot's location, which is also the time zone of NOW() in postgres,
+// and are read back without it.
+func (h *Handlers) timestamp(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), h.Location)
+}
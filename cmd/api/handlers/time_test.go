@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load location %s: %v", name, err)
+	}
+	return location
+}
+
+func TestNextExpiry(t *testing.T) {
+	singapore := mustLoadLocation(t, "Asia/Singapore")
+	newYork := mustLoadLocation(t, "America/New_York")
+	london := mustLoadLocation(t, "Europe/London")
+	sydney := mustLoadLocation(t, "Australia/Sydney")
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		hour, min    int
+		want         time.Time
+		wantTomorrow bool
+	}{
+		{"later today", time.Date(2021, 3, 10, 9, 0, 0, 0, singapore), 12, 30,
+			time.Date(2021, 3, 10, 12, 30, 0, 0, singapore), false},
+		{"now", time.Date(2021, 3, 10, 12, 30, 0, 0, singapore), 12, 30,
+			time.Date(2021, 3, 10, 12, 30, 0, 0, singapore), false},
+		{"midnight rollover", time.Date(2021, 3, 10, 23, 50, 0, 0, singapore), 0, 0,
+			time.Date(2021, 3, 11, 0, 0, 0, 0, singapore), true},
+		{"month end", time.Date(2021, 1, 31, 23, 0, 0, 0, singapore), 0, 30,
+			time.Date(2021, 2, 1, 0, 30, 0, 0, singapore), true},
+		{"leap day", time.Date(2020, 2, 28, 22, 0, 0, 0, singapore), 8, 0,
+			time.Date(2020, 2, 29, 8, 0, 0, 0, singapore), true},
+		{"year end", time.Date(2020, 12, 31, 23, 30, 0, 0, singapore), 0, 15,
+			time.Date(2021, 1, 1, 0, 15, 0, 0, singapore), true},
+		{"dst starts tomorrow", time.Date(2021, 3, 13, 20, 0, 0, 0, newYork), 12, 0,
+			time.Date(2021, 3, 14, 12, 0, 0, 0, newYork), true},
+		{"dst ends tomorrow", time.Date(2021, 10, 30, 20, 0, 0, 0, london), 12, 0,
+			time.Date(2021, 10, 31, 12, 0, 0, 0, london), true},
+		{"dst skipped time", time.Date(2021, 3, 14, 1, 0, 0, 0, newYork), 2, 30,
+			time.Date(2021, 3, 14, 3, 30, 0, 0, newYork), false},
+		{"dst skipped time london", time.Date(2021, 3, 28, 0, 30, 0, 0, london), 1, 30,
+			time.Date(2021, 3, 28, 2, 30, 0, 0, london), false},
+		{"dst skipped time sydney", time.Date(2021, 10, 3, 1, 0, 0, 0, sydney), 2, 30,
+			time.Date(2021, 10, 3, 3, 30, 0, 0, sydney), false},
+		{"dst skipped time tomorrow", time.Date(2021, 10, 2, 20, 0, 0, 0, sydney), 2, 30,
+			time.Date(2021, 10, 3, 3, 30, 0, 0, sydney), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, tomorrow := nextExpiry(tt.now, tt.hour, tt.min)
+			if !got.Equal(tt.want) || tomorrow != tt.wantTomorrow {
+				t.Fatalf("expected %v, %v, got %v, %v", tt.want, tt.wantTomorrow, got, tomorrow)
+			}
+			if got.Hour() != tt.want.Hour() || got.Minute() != tt.want.Minute() {
+				t.Fatalf("expected wall clock %s, got %s", tt.want.Format("15:04"), got.Format("15:04"))
+			}
+		})
+	}
+}
+
+func TestIsAfterToday(t *testing.T) {
+	singapore := mustLoadLocation(t, "Asia/Singapore")
+	// expiry is read back from a TIMESTAMP column as wall clock time without a zone
+	timestamp := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.FixedZone("", 0))
+	}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		expiry time.Time
+		want   bool
+	}{
+		{"same day", time.Date(2021, 3, 10, 9, 0, 0, 0, singapore), timestamp(2021, 3, 10, 12, 0), false},
+		{"next day", time.Date(2021, 3, 10, 23, 0, 0, 0, singapore), timestamp(2021, 3, 11, 0, 30), true},
+		{"past midnight", time.Date(2021, 3, 11, 0, 10, 0, 0, singapore), timestamp(2021, 3, 11, 0, 30), false},
+		{"month end", time.Date(2021, 1, 31, 23, 0, 0, 0, singapore), timestamp(2021, 2, 1, 0, 30), true},
+		{"year end", time.Date(2020, 12, 31, 23, 0, 0, 0, singapore), timestamp(2021, 1, 1, 0, 30), true},
+		{"utc date differs", time.Date(2021, 3, 10, 7, 0, 0, 0, singapore), timestamp(2021, 3, 10, 9, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAfterToday(tt.now, tt.expiry); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,10 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"strings"
-	"time"
 )
 
 // ContextKey is the unique key that represents a context value
@@ -43,7 +41,6 @@ func respondWithStatus(w http.ResponseWriter, statusCode int, data map[string]in
 }
 
 func escapeString(str string) string {
-	now := time.Now()
 	str = strings.ReplaceAll(str, "\\", "\\\\")
 	str = strings.ReplaceAll(str, "`", "\\`")
 	str = strings.ReplaceAll(str, "*", "\\*")
@@ -63,6 +60,5 @@ func escapeString(str string) string {
 	str = strings.ReplaceAll(str, "&", "&amp;")
 	str = strings.ReplaceAll(str, "<", "&lt;")
 	str = strings.ReplaceAll(str, ">", "&gt;")
-	log.Println(time.Now().Sub(now).Milliseconds())
 	return str
 }
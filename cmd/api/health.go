@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gocraft/work"
+	"github.com/gpng/order-bot/cmd/api/config"
+	"github.com/gpng/order-bot/services/health"
+	"github.com/gpng/order-bot/services/telegram"
+)
+
+// jobsHeartbeatMaxAge is how long the jobs component can go without a heartbeat and stay ready.
+// gocraft/work pools heartbeat every 5s and the postgres scheduler polls every second.
+const jobsHeartbeatMaxAge = 30 * time.Second
+
+// readinessChecks of the dependencies of this instance
+func readinessChecks(cfg config.Config, db *sql.DB, backend *jobBackend, bot *telegram.Bot) *health.Checker {
+	checker := health.New(cfg.ReadyTimeout)
+	checker.Add("postgres", db.PingContext)
+
+	switch {
+	case backend.redisPool != nil:
+		checker.Add("redis", func(ctx context.Context) error {
+			conn, err := backend.redisPool.GetContext(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			_, err = conn.Do("PING")
+			return err
+		})
+		checker.Add("jobs", func(ctx context.Context) error {
+			return checkWorkHeartbeat(work.NewClient(cfg.RedisNamespace, backend.redisPool))
+		})
+	case backend.postgres != nil:
+		checker.Add("jobs", func(ctx context.Context) error {
+			return checkHeartbeat(backend.postgres.Heartbeat())
+		})
+	}
+
+	if cfg.ReadyCheckTelegram {
+		checker.Add("telegram", bot.Ping)
+	}
+	return checker
+}
+
+// checkWorkHeartbeat of the gocraft/work pool of this process
+func checkWorkHeartbeat(client *work.Client) error {
+	heartbeats, err := client.WorkerPoolHeartbeats()
+	if err != nil {
+		return err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	var last time.Time
+	for _, heartbeat := range heartbeats {
+		if heartbeat.Pid != os.Getpid() || heartbeat.Host != hostname {
+			continue
+		}
+		if at := time.Unix(heartbeat.HeartbeatAt, 0); at.After(last) {
+			last = at
+		}
+	}
+	return checkHeartbeat(last)
+}
+
+func checkHeartbeat(last time.Time) error {
+	if last.IsZero() {
+		return errors.New("no heartbeat")
+	}
+	if age := time.Since(last); age > jobsHeartbeatMaxAge {
+		return fmt.Errorf("last heartbeat %s ago", age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gpng/order-bot/cmd/api/config"
+	"github.com/gpng/order-bot/services/postgres"
+	"github.com/gpng/order-bot/services/scheduler"
+	"go.uber.org/zap"
+)
+
+const jobsUsage = `usage:
+  api jobs list
+  api jobs cancel <handle>`
+
+// runJobs runs the jobs subcommand against JOB_BACKEND
+func runJobs(cfg config.Config, l *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(jobsUsage)
+	}
+
+	db, err := postgres.New(cfg.DbHost, cfg.DbUser, cfg.DbName, cfg.DbPassword, cfg.Timezone)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	backend, err := openJobBackend(cfg, db, l)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return errors.New(jobsUsage)
+		}
+		return listJobs(os.Stdout, backend.scheduler)
+	case "cancel":
+		if len(args) != 2 {
+			return errors.New(jobsUsage)
+		}
+		if err := backend.scheduler.Cancel(context.Background(), scheduler.Handle(args[1])); err != nil {
+			return err
+		}
+		fmt.Printf("cancelled job %s\n", args[1])
+		return nil
+	}
+	return errors.New(jobsUsage)
+}
+
+// listJobs that are pending, soonest first
+func listJobs(out io.Writer, jobScheduler scheduler.Scheduler) error {
+	scheduled, err := jobScheduler.List(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HANDLE\tNAME\tRUN AT\tARGS")
+	for _, s := range scheduled {
+		args, err := json.Marshal(s.Job.Args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Handle, s.Job.Name, s.At.UTC().Format(time.RFC3339), args)
+	}
+	return w.Flush()
+}
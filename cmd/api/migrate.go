@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gpng/order-bot/cmd/api/config"
+	"github.com/gpng/order-bot/cmd/api/handlers"
+	"github.com/gpng/order-bot/services/migrate"
+	"github.com/gpng/order-bot/services/postgres"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+const migrateUsage = "usage: api migrate status|up|down|relink-ids"
+
+// runMigrate runs the migrate subcommand
+func runMigrate(cfg config.Config, l *zap.Logger, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := postgres.New(cfg.DbHost, cfg.DbUser, cfg.DbName, cfg.DbPassword, cfg.Timezone)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "status":
+		return migrate.Status(ctx, db)
+	case "up":
+		if err := migrate.Up(ctx, db); err != nil {
+			return err
+		}
+		log.Println("migrations applied")
+		return nil
+	case "down":
+		if err := migrate.Down(ctx, db); err != nil {
+			return err
+		}
+		log.Println("migration rolled back")
+		return nil
+	case "relink-ids":
+		summary, err := handlers.RelinkWrappedIDs(ctx, models.NewStore(db), l)
+		if err != nil {
+			return err
+		}
+		log.Printf("relinked %d chats and %d users, skipped %d", summary.Chats, summary.Users, summary.Skipped)
+		return nil
+	}
+	return errors.New(migrateUsage)
+}
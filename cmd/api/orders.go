@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gpng/order-bot/cmd/api/config"
+	"github.com/gpng/order-bot/services/postgres"
+	"github.com/gpng/order-bot/services/scheduler"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+const ordersUsage = `usage:
+  api orders list --chat <chat id>
+  api orders close <order id>`
+
+// runOrders runs the orders subcommand
+func runOrders(cfg config.Config, l *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(ordersUsage)
+	}
+
+	db, err := postgres.New(cfg.DbHost, cfg.DbUser, cfg.DbName, cfg.DbPassword, cfg.Timezone)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	repo := models.NewStore(db)
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("orders list", flag.ContinueOnError)
+		chatID := flags.Int64("chat", 0, "chat id")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *chatID == 0 {
+			return errors.New(ordersUsage)
+		}
+		return listOrders(os.Stdout, repo, *chatID)
+	case "close":
+		if len(args) != 2 {
+			return errors.New(ordersUsage)
+		}
+		orderID, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid order id %q", args[1])
+		}
+
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return fmt.Errorf("failed to load TIMEZONE: %w", err)
+		}
+
+		backend, err := openJobBackend(cfg, db, l)
+		if err != nil {
+			return err
+		}
+		defer backend.Close()
+		return closeOrder(os.Stdout, repo, backend.scheduler, location, int32(orderID))
+	}
+	return errors.New(ordersUsage)
+}
+
+// listOrders of the chat, newest first
+func listOrders(out io.Writer, repo models.Querier, chatID int64) error {
+	orders, err := repo.GetOrdersByChatID(context.Background(), chatID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tEXPIRY\tACTIVE\tREMINDER JOB\tEXPIRY JOB")
+	for _, order := range orders {
+		expiry := "-"
+		if order.Expiry.Valid {
+			// TIMESTAMP columns keep the wall clock in TIMEZONE
+			expiry = order.Expiry.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%s\n",
+			order.ID, order.Title, expiry, order.Active, nullString(order.ReminderID), nullString(order.ExpiryID))
+	}
+	return w.Flush()
+}
+
+// closeOrder deactivates the order and cancels its jobs, without notifying the chat.
+// The close event is recorded at the wall clock time in location, like events recorded by the bot.
+func closeOrder(out io.Writer, repo models.Store, jobScheduler scheduler.Scheduler, location *time.Location, orderID int32) error {
+	ctx := context.Background()
+	order, err := repo.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("order %d not found", orderID)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, id := range []sql.NullString{order.ReminderID, order.ExpiryID} {
+		if !id.Valid {
+			continue
+		}
+		err := jobScheduler.Cancel(ctx, scheduler.Handle(id.String))
+		if errors.Is(err, scheduler.ErrInvalidHandle) {
+			fmt.Fprintf(out, "skipped job %s of another job backend\n", id.String)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to cancel job %s: %w", id.String, err)
+		}
+		fmt.Fprintf(out, "cancelled job %s\n", id.String)
+	}
+
+	if !order.Active {
+		fmt.Fprintf(out, "order %d was already closed\n", order.ID)
+		return nil
+	}
+	err = repo.ExecTx(ctx, func(q models.Querier) error {
+		if err := q.DeactivateOrder(ctx, order.ID); err != nil {
+			return err
+		}
+		_, err := q.CreateOrderEvent(ctx, models.CreateOrderEventParams{
+			OrderID:   order.ID,
+			Type:      models.OrderEventClose,
+			CreatedAt: time.Now().In(location),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "closed order %d\n", order.ID)
+	return nil
+}
+
+func nullString(s sql.NullString) string {
+	if !s.Valid {
+		return "-"
+	}
+	return s.String
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/gocraft/work"
+	"github.com/gpng/order-bot/cmd/api/config"
+	"github.com/gpng/order-bot/cmd/api/handlers"
+	"github.com/gpng/order-bot/services/clock"
+	"github.com/gpng/order-bot/services/metrics"
+	"github.com/gpng/order-bot/services/migrate"
+	"github.com/gpng/order-bot/services/postgres"
+	"github.com/gpng/order-bot/services/scheduler"
+	"github.com/gpng/order-bot/services/supervisor"
+	"github.com/gpng/order-bot/services/telegram"
+	"github.com/gpng/order-bot/services/tracing"
+	"github.com/gpng/order-bot/services/version"
+	"github.com/gpng/order-bot/services/workerpool"
+	"github.com/gpng/order-bot/sqlc/models"
+	"go.uber.org/zap"
+)
+
+// runServe runs the bot until SIGINT or SIGTERM. Workers only run scheduled jobs and the reconciler,
+// without receiving updates.
+func runServe(cfg config.Config, l *zap.Logger, workerOnly bool) error {
+	log.Printf("order-bot %s (%s)", version.Version, version.Commit)
+
+	db, err := postgres.New(cfg.DbHost, cfg.DbUser, cfg.DbName, cfg.DbPassword, cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("failed to initialise DB connection: %w", err)
+	}
+	// deferred closes run after the components stop, redis first and then the db
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("failed to close db: %v", err)
+		}
+	}()
+
+	if cfg.MigrateOnStart {
+		if err := migrate.Up(context.Background(), db); err != nil {
+			return fmt.Errorf("failed to migrate: %w", err)
+		}
+		log.Println("migrations applied")
+	}
+
+	if cfg.OtlpEndpoint != "" {
+		shutdownTracing, err := tracing.Start(context.Background(), "order-bot")
+		if err != nil {
+			return fmt.Errorf("failed to start tracing: %w", err)
+		}
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				log.Printf("failed to flush spans: %v", err)
+			}
+		}()
+	}
+
+	repo := models.NewStore(db)
+	metrics.RegisterActiveOrders(repo.CountActiveOrders)
+
+	bot, err := telegram.New(cfg.BotToken)
+	if err != nil {
+		return fmt.Errorf("failed to initialise bot: %w", err)
+	}
+	// bot.BotAPI.Debug = true
+
+	webhookPath := path.Join("/", cfg.WebhookPath)
+	if !workerOnly {
+		if err := setUpdateMode(cfg, bot, webhookPath); err != nil {
+			return err
+		}
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("failed to load TIMEZONE: %w", err)
+	}
+
+	backend, err := openJobBackend(cfg, db, l)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := backend.Close(); err != nil {
+			log.Printf("failed to close redis pool: %v", err)
+		}
+	}()
+
+	var updatePool *workerpool.Pool
+	if !workerOnly {
+		updatePool = workerpool.New(cfg.UpdateWorkers, cfg.UpdateQueue, l)
+	}
+
+	checker := readinessChecks(cfg, db, backend, bot)
+
+	h := handlers.New(cfg.BotToken, webhookPath, cfg.WebhookSecret, location, l, db, repo, bot, backend.scheduler, clock.Real{}, backend.dedup, updatePool, checker)
+
+	if !workerOnly {
+		// commands still work without suggestions, so a failure is not fatal
+		if err := h.RegisterCommands(context.Background(), bot); err != nil {
+			log.Printf("failed to register commands: %v", err)
+		}
+	}
+
+	// components are stopped in this order, so updates stop arriving before the queues they feed are drained
+	var components []supervisor.Component
+	if !workerOnly {
+		components = append(components, updateComponents(cfg, h, bot, updatePool)...)
+	} else {
+		// workers only serve metrics and health checks
+		router := mainRouter()
+		router.Mount("/", h.HealthRoutes())
+		components = append(components, httpComponent(cfg, router))
+	}
+
+	switch {
+	case backend.redisPool != nil:
+		pool := work.NewWorkerPool(handlers.Handlers{}, 10, cfg.RedisNamespace, backend.redisPool)
+
+		pool.Middleware(func(c *handlers.Handlers, job *work.Job, next work.NextMiddlewareFunc) error {
+			c.Logger = l
+			c.DB = db
+			c.Repo = repo
+			c.Bot = bot
+			c.Scheduler = backend.scheduler
+			c.Clock = clock.Real{}
+			c.Location = location
+			err := next()
+			metrics.ObserveJob(job.Name, err)
+			return err
+		})
+
+		pool.JobWithOptions(string(handlers.JobNotifyExpiry), work.JobOptions{
+			MaxConcurrency: 1,
+			MaxFails:       3,
+		}, (*handlers.Handlers).JobNotifyExpiry)
+
+		components = append(components, supervisor.Component{
+			Name: "jobs",
+			Run: func(ctx context.Context) error {
+				log.Println("starting worker pool...")
+				pool.Start()
+				<-ctx.Done()
+				return nil
+			},
+			// Stop waits for running jobs and can't be cancelled, so it is left running in the background after ctx is done
+			Stop: func(ctx context.Context) error {
+				stopped := make(chan struct{})
+				go func() {
+					pool.Stop()
+					close(stopped)
+				}()
+				select {
+				case <-stopped:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		})
+	case backend.postgres != nil:
+		components = append(components, supervisor.Component{
+			Name: "jobs",
+			Run: func(ctx context.Context) error {
+				log.Println("polling for scheduled jobs...")
+				backend.postgres.Run(ctx, func(ctx context.Context, job scheduler.Job) error {
+					err := h.RunJob(ctx, job)
+					metrics.ObserveJob(job.Name, err)
+					return err
+				})
+				return nil
+			},
+		})
+	}
+
+	components = append(components, supervisor.Component{
+		Name: "reconciler",
+		Run: func(ctx context.Context) error {
+			h.RunReconciler(ctx, cfg.ReconcileInterval)
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signalChan
+		log.Printf("received %v, shutting down", sig)
+		cancel()
+	}()
+
+	if err := supervisor.New(l, components...).Run(ctx, cfg.ShutdownTimeout); err != nil {
+		return fmt.Errorf("stopped after error: %w", err)
+	}
+	log.Println("shutdown complete")
+	return nil
+}
+
+// setUpdateMode registers or removes the webhook for UPDATE_MODE
+func setUpdateMode(cfg config.Config, bot *telegram.Bot, webhookPath string) error {
+	switch cfg.UpdateMode {
+	case config.UpdateModeWebhook:
+		if cfg.WebhookSecret == "" {
+			return errors.New("WEBHOOK_SECRET is required to verify webhook requests")
+		}
+		if cfg.WebhookURL == "" {
+			log.Println("WEBHOOK_URL not set, skipping webhook registration")
+			return nil
+		}
+		if err := bot.SetWebhook(strings.TrimSuffix(cfg.WebhookURL, "/")+webhookPath, cfg.WebhookSecret); err != nil {
+			return fmt.Errorf("failed to set webhook: %w", err)
+		}
+		log.Println("webhook registered")
+		return nil
+	case config.UpdateModePolling:
+		// getUpdates does not work while a webhook is set
+		if err := bot.RemoveWebhook(); err != nil {
+			return fmt.Errorf("failed to remove webhook: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid UPDATE_MODE: %s", cfg.UpdateMode)
+}
+
+// updateComponents receive updates through the http server or polling, and process them on the update pool
+func updateComponents(cfg config.Config, h *handlers.Handlers, bot *telegram.Bot, updatePool *workerpool.Pool) []supervisor.Component {
+	// initialise main router with basic middlewares, cors settings etc
+	router := mainRouter()
+
+	router.Mount("/", h.Routes())
+
+	components := []supervisor.Component{httpComponent(cfg, router)}
+	if cfg.UpdateMode == config.UpdateModePolling {
+		components = append(components, supervisor.Component{
+			Name: "polling",
+			Run: func(ctx context.Context) error {
+				log.Println("polling for updates...")
+				h.PollUpdates(ctx, bot)
+				return nil
+			},
+		})
+	}
+	return append(components, supervisor.Component{
+		Name: "updates",
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+		Stop: updatePool.Shutdown,
+	})
+}
+
+// httpComponent serves handler on PORT
+func httpComponent(cfg config.Config, handler http.Handler) supervisor.Component {
+	server := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: handler,
+	}
+	return supervisor.Component{
+		Name: "http",
+		Run: func(ctx context.Context) error {
+			log.Println("listening to port " + cfg.Port)
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		Stop: server.Shutdown,
+	}
+}
+
+func mainRouter() chi.Router {
+	router := chi.NewRouter()
+
+	// A good base middleware stack
+	router.Use(middleware.RequestID)
+	router.Use(middleware.RealIP)
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+
+	// stop crawlers
+	router.Get("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /"))
+	})
+
+	router.Handle("/metrics", metrics.Handler())
+
+	return router
+}
@@ -1,6 +1,6 @@
 module github.com/gpng/order-bot
 
-go 1.15
+go 1.16
 
 require (
 	github.com/caarlos0/env v3.5.0+incompatible
@@ -13,16 +13,14 @@ require (
 	github.com/gomodule/redigo v1.8.3
 	github.com/jinzhu/gorm v1.9.16
 	github.com/joho/godotenv v1.3.0
-	github.com/kr/text v0.2.0 // indirect
-	github.com/lib/pq v1.9.0
-	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
+	github.com/lib/pq v1.10.4
+	github.com/pressly/goose/v3 v3.5.3
+	github.com/prometheus/client_golang v1.9.0
 	github.com/robfig/cron v1.2.0 // indirect
-	github.com/stretchr/testify v1.6.1 // indirect
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
 	go.uber.org/zap v1.16.0
-	golang.org/x/net v0.0.0-20201207224615-747e23833adb // indirect
-	golang.org/x/text v0.3.4 // indirect
-	golang.org/x/tools v0.0.0-20201208062317-e652b2f42cc7 // indirect
-	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
+	golang.org/x/time v0.0.0-20201208040808-7e3f01d25324
 )
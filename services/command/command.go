@@ -0,0 +1,288 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// errors returned by Parse for messages the bot should ignore
+var (
+	ErrNotCommand     = errors.New("not a command for the bot")
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+// ErrorKind of an Error
+type ErrorKind int
+
+// kinds of invalid commands
+const (
+	ErrMissingArg ErrorKind = iota
+	ErrInvalidArg
+	ErrInvalidOption
+	ErrUnterminatedQuote
+)
+
+// Error of a known command with invalid args, Name and Value are those of the arg or option
+type Error struct {
+	Spec  Spec
+	Kind  ErrorKind
+	Name  string
+	Value string
+}
+
+func (e *Error) Error() string {
+	switch e.Kind {
+	case ErrMissingArg:
+		return fmt.Sprintf("/%s: missing %s", e.Spec.Name, e.Name)
+	case ErrInvalidArg:
+		return fmt.Sprintf("/%s: invalid %s %q", e.Spec.Name, e.Name, e.Value)
+	case ErrInvalidOption:
+		return fmt.Sprintf("/%s: invalid %s=%q", e.Spec.Name, e.Name, e.Value)
+	case ErrUnterminatedQuote:
+		return fmt.Sprintf("/%s: missing closing quote", e.Spec.Name)
+	}
+	return fmt.Sprintf("/%s: invalid command", e.Spec.Name)
+}
+
+// Arg of a command, given in order
+type Arg struct {
+	Name string
+	// Optional args are skipped when the next word does not Match
+	Optional bool
+	// Rest is the remaining words joined by spaces, it must be the last arg
+	Rest bool
+	// Match reports whether value is valid, any value is when nil
+	Match func(value string) bool
+}
+
+// Option of a command, given anywhere as name=value
+type Option struct {
+	Name  string
+	Match func(value string) bool
+}
+
+// Spec of a command
+type Spec struct {
+	// Name without the slash
+	Name    string
+	Aliases []string
+	// Description shown by help, such as a message key
+	Description string
+	Args        []Arg
+	Options     []Option
+}
+
+// Usage of the command, such as /takeorders [time] <title> where optional args are in brackets
+func (s Spec) Usage() string {
+	parts := []string{"/" + s.Name}
+	for _, arg := range s.Args {
+		if arg.Optional {
+			parts = append(parts, "["+arg.Name+"]")
+		} else {
+			parts = append(parts, "<"+arg.Name+">")
+		}
+	}
+	for _, option := range s.Options {
+		parts = append(parts, "["+option.Name+"=…]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s Spec) option(name string) (Option, bool) {
+	for _, option := range s.Options {
+		if strings.EqualFold(option.Name, name) {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+// Command parsed from a message
+type Command struct {
+	// Name of the spec, aliases are resolved
+	Name    string
+	Args    map[string]string
+	Options map[string]string
+}
+
+// Arg value, empty if it was not given
+func (c Command) Arg(name string) string {
+	return c.Args[name]
+}
+
+// Option value, empty if it was not given
+func (c Command) Option(name string) string {
+	return c.Options[name]
+}
+
+// Set of the commands of a bot
+type Set struct {
+	specs  []Spec
+	byName map[string]Spec
+}
+
+// NewSet of specs, panicking on duplicate names as specs are static
+func NewSet(specs ...Spec) *Set {
+	s := &Set{specs: specs, byName: map[string]Spec{}}
+	for _, spec := range specs {
+		for _, name := range append([]string{spec.Name}, spec.Aliases...) {
+			name = strings.ToLower(name)
+			if _, ok := s.byName[name]; ok {
+				panic("command: duplicate command " + name)
+			}
+			s.byName[name] = spec
+		}
+	}
+	return s
+}
+
+// Specs in the order they were added
+func (s *Set) Specs() []Spec {
+	return s.specs
+}
+
+// Spec named name or one of its aliases
+func (s *Set) Spec(name string) (Spec, bool) {
+	spec, ok := s.byName[strings.ToLower(name)]
+	return spec, ok
+}
+
+// Parse text such as /order@botname 2 "kopi o" into a command of the set.
+// Commands addressed to other bots return ErrNotCommand, those not in the set ErrUnknownCommand.
+// Invalid args return an *Error, extra words are ignored.
+func (s *Set) Parse(text string, botUsername string) (Command, error) {
+	text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	if !strings.HasPrefix(text, "/") {
+		return Command{}, ErrNotCommand
+	}
+
+	name := text[1:]
+	rest := ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		name, rest = text[1:i], text[i:]
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		if !strings.EqualFold(name[i+1:], botUsername) {
+			return Command{}, ErrNotCommand
+		}
+		name = name[:i]
+	}
+
+	spec, ok := s.byName[strings.ToLower(name)]
+	if !ok {
+		return Command{}, ErrUnknownCommand
+	}
+
+	tokens, ok := tokenize(rest)
+	if !ok {
+		return Command{}, &Error{Spec: spec, Kind: ErrUnterminatedQuote}
+	}
+
+	cmd := Command{Name: spec.Name, Args: map[string]string{}, Options: map[string]string{}}
+	var words []string
+	for _, t := range tokens {
+		// only unquoted names are options, so that "a=b" can be given as an arg
+		if t.eq > 0 {
+			if option, ok := spec.option(t.text[:t.eq]); ok {
+				value := t.text[t.eq+1:]
+				if option.Match != nil && !option.Match(value) {
+					return Command{}, &Error{Spec: spec, Kind: ErrInvalidOption, Name: option.Name, Value: value}
+				}
+				cmd.Options[option.Name] = value
+				continue
+			}
+		}
+		words = append(words, t.text)
+	}
+
+	for _, arg := range spec.Args {
+		if len(words) == 0 {
+			if !arg.Optional {
+				return Command{}, &Error{Spec: spec, Kind: ErrMissingArg, Name: arg.Name}
+			}
+			continue
+		}
+
+		value := words[0]
+		if arg.Rest {
+			value = strings.Join(words, " ")
+		}
+		if arg.Match != nil && !arg.Match(value) {
+			if arg.Optional {
+				continue
+			}
+			return Command{}, &Error{Spec: spec, Kind: ErrInvalidArg, Name: arg.Name, Value: value}
+		}
+		cmd.Args[arg.Name] = value
+		if arg.Rest {
+			words = nil
+		} else {
+			words = words[1:]
+		}
+	}
+
+	return cmd, nil
+}
+
+// token of a command, eq is the index of the first = in text before any quote, or -1
+type token struct {
+	text string
+	eq   int
+}
+
+// quotes that start and end quoted text, phones often replace " with curly quotes
+var quotes = map[rune]rune{'"': '"', '“': '”', '„': '“'}
+
+// tokenize splits text on whitespace, keeping whitespace in quotes, where \ escapes the next character.
+// Quotes only start quoted text at the start of a token, so an inch mark such as 12" is kept as is.
+// It returns false if a quote is not closed.
+func tokenize(text string) ([]token, bool) {
+	var tokens []token
+	var b strings.Builder
+	inToken := false
+	quoted := false
+	eq := -1
+	var closing rune
+	escaped := false
+
+	for _, r := range text {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case closing != 0 && r == '\\':
+			escaped = true
+		case closing != 0 && r == closing:
+			closing = 0
+		case closing != 0:
+			b.WriteRune(r)
+		case quotes[r] != 0 && !inToken:
+			closing = quotes[r]
+			inToken = true
+			quoted = true
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, token{b.String(), eq})
+				b.Reset()
+				inToken = false
+				quoted = false
+				eq = -1
+			}
+		default:
+			if r == '=' && eq < 0 && !quoted {
+				eq = b.Len()
+			}
+			b.WriteRune(r)
+			inToken = true
+		}
+	}
+	if closing != 0 {
+		return nil, false
+	}
+	if inToken {
+		tokens = append(tokens, token{b.String(), eq})
+	}
+	return tokens, true
+}
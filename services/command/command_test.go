@@ -0,0 +1,114 @@
+package command
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var isNumber = regexp.MustCompile(`^[0-9]+$`).MatchString
+
+var set = NewSet(
+	Spec{
+		Name:    "order",
+		Aliases: []string{"o"},
+		Args: []Arg{
+			{Name: "quantity", Optional: true, Match: isNumber},
+			{Name: "item", Rest: true},
+		},
+	},
+	Spec{
+		Name:    "log",
+		Options: []Option{{Name: "limit", Match: isNumber}},
+	},
+	Spec{
+		Name: "apikey",
+		Args: []Arg{
+			{Name: "action"},
+			{Name: "id", Optional: true, Match: isNumber},
+		},
+	},
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Command
+		wantErr error
+	}{
+		{"args", "/order 2 kopi o", Command{"order", map[string]string{"quantity": "2", "item": "kopi o"}, map[string]string{}}, nil},
+		{"optional arg skipped", "/order kopi", Command{"order", map[string]string{"item": "kopi"}, map[string]string{}}, nil},
+		{"whitespace", "  /order\n2   kopi\to ", Command{"order", map[string]string{"quantity": "2", "item": "kopi o"}, map[string]string{}}, nil},
+		{"alias and case", "/O 2 kopi", Command{"order", map[string]string{"quantity": "2", "item": "kopi"}, map[string]string{}}, nil},
+		{"mention", "/order@OrderBot kopi", Command{"order", map[string]string{"item": "kopi"}, map[string]string{}}, nil},
+		{"quotes", `/order "2 kopi" "o  kosong"`, Command{"order", map[string]string{"item": "2 kopi o  kosong"}, map[string]string{}}, nil},
+		{"curly quotes", "/order “kopi o” 2", Command{"order", map[string]string{"item": "kopi o 2"}, map[string]string{}}, nil},
+		{"escaped quote", `/order "12\" pizza"`, Command{"order", map[string]string{"item": `12" pizza`}, map[string]string{}}, nil},
+		{"inch mark", `/order 1 12" pizza`, Command{"order", map[string]string{"quantity": "1", "item": `12" pizza`}, map[string]string{}}, nil},
+		{"quote in a word", `/order Joe"s kopi`, Command{"order", map[string]string{"item": `Joe"s kopi`}, map[string]string{}}, nil},
+		{"option", "/log limit=5", Command{"log", map[string]string{}, map[string]string{"limit": "5"}}, nil},
+		{"unknown option is an arg", "/order kopi=strong", Command{"order", map[string]string{"item": "kopi=strong"}, map[string]string{}}, nil},
+		{"extra words", "/apikey revoke 1 now", Command{"apikey", map[string]string{"action": "revoke", "id": "1"}, map[string]string{}}, nil},
+		{"other bot", "/order@OtherBot kopi", Command{}, ErrNotCommand},
+		{"not a command", "kopi", Command{}, ErrNotCommand},
+		{"unknown command", "/unknown", Command{}, ErrUnknownCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := set.Parse(tt.text, "orderbot")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) err = %v, want %v", tt.text, err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want Error
+	}{
+		{"/order", Error{Kind: ErrMissingArg, Name: "item"}},
+		{"/order 2", Error{Kind: ErrMissingArg, Name: "item"}},
+		{"/apikey", Error{Kind: ErrMissingArg, Name: "action"}},
+		{"/log limit=all", Error{Kind: ErrInvalidOption, Name: "limit", Value: "all"}},
+		{`/order "kopi`, Error{Kind: ErrUnterminatedQuote}},
+	}
+	for _, tt := range tests {
+		_, err := set.Parse(tt.text, "orderbot")
+		var got *Error
+		if !errors.As(err, &got) {
+			t.Fatalf("Parse(%q) err = %v, want *Error", tt.text, err)
+		}
+		if got.Kind != tt.want.Kind || got.Name != tt.want.Name || got.Value != tt.want.Value {
+			t.Errorf("Parse(%q) err = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	want := map[string]string{
+		"order":  "/order [quantity] <item>",
+		"log":    "/log [limit=…]",
+		"apikey": "/apikey <action> [id]",
+	}
+	for _, spec := range set.Specs() {
+		if got := spec.Usage(); got != want[spec.Name] {
+			t.Errorf("Usage = %q, want %q", got, want[spec.Name])
+		}
+	}
+}
+
+func TestSpec(t *testing.T) {
+	if spec, ok := set.Spec("O"); !ok || spec.Name != "order" {
+		t.Fatalf("Spec(O) = %+v, %v, want order", spec, ok)
+	}
+	if _, ok := set.Spec("unknown"); ok {
+		t.Fatalf("Spec(unknown) found a spec")
+	}
+}
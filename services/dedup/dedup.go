@@ -0,0 +1,51 @@
+package dedup
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Deduplicator records processed ids so redeliveries can be skipped.
+// Ids are marked before they are processed and are kept if processing fails, as a failed update may have been
+// partly applied, such as an order saved before its reply failed to send.
+type Deduplicator interface {
+	// Seen marks id as processed and returns true if it was already marked
+	Seen(id int) (bool, error)
+}
+
+// Redis records processed ids in redis
+type Redis struct {
+	pool      *redis.Pool
+	namespace string
+	ttl       time.Duration
+}
+
+var _ Deduplicator = (*Redis)(nil)
+
+// NewRedis deduplicator, ids are forgotten after ttl
+func NewRedis(pool *redis.Pool, namespace string, ttl time.Duration) *Redis {
+	return &Redis{pool, namespace, ttl}
+}
+
+// Seen marks id as processed and returns true if it was already marked
+func (d *Redis) Seen(id int) (bool, error) {
+	conn := d.pool.Get()
+	defer conn.Close()
+
+	// SET NX only replies OK for the first caller
+	_, err := redis.String(conn.Do("SET", d.key(id), 1, "EX", int64(d.ttl.Seconds()), "NX"))
+	if errors.Is(err, redis.ErrNil) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (d *Redis) key(id int) string {
+	return d.namespace + ":updates:" + strconv.Itoa(id)
+}
@@ -0,0 +1,60 @@
+package dedup
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// how often expired ids are deleted
+const postgresPruneInterval = time.Hour
+
+// Postgres records processed ids in the processed_updates table
+type Postgres struct {
+	db  *sql.DB
+	ttl time.Duration
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+var _ Deduplicator = (*Postgres)(nil)
+
+// NewPostgres deduplicator, ids are forgotten after ttl
+func NewPostgres(db *sql.DB, ttl time.Duration) *Postgres {
+	return &Postgres{db: db, ttl: ttl}
+}
+
+// Seen marks id as processed and returns true if it was already marked
+func (d *Postgres) Seen(id int) (bool, error) {
+	now := time.Now()
+	d.prune(now)
+
+	// only the first caller inserts a row, or replaces an expired one
+	res, err := d.db.Exec(`
+INSERT INTO processed_updates (update_id, processed_at)
+VALUES ($1, $2)
+ON CONFLICT (update_id) DO UPDATE SET processed_at = EXCLUDED.processed_at
+WHERE processed_updates.processed_at <= $3`, id, now.Unix(), now.Add(-d.ttl).Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 0, nil
+}
+
+// prune deletes expired ids at most once per postgresPruneInterval, failures are retried on the next interval
+func (d *Postgres) prune(now time.Time) {
+	d.mu.Lock()
+	if now.Sub(d.lastPrune) < postgresPruneInterval {
+		d.mu.Unlock()
+		return
+	}
+	d.lastPrune = now
+	d.mu.Unlock()
+
+	d.db.Exec(`DELETE FROM processed_updates WHERE processed_at <= $1`, now.Add(-d.ttl).Unix())
+}
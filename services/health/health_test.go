@@ -0,0 +1,52 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/services/health"
+)
+
+func TestReportsEachCheck(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	c := health.New(50 * time.Millisecond)
+	c.Add("postgres", func(ctx context.Context) error { return nil })
+	c.Add("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+	// ignores ctx, like clients that can't be cancelled
+	c.Add("telegram", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	report := c.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the blocked check to be abandoned, took %v", elapsed)
+	}
+
+	if report.OK() {
+		t.Fatalf("expected report to fail, got %+v", report)
+	}
+	if got := report.Checks["postgres"]; got.Status != health.StatusOK {
+		t.Fatalf("postgres = %+v, want ok", got)
+	}
+	if got := report.Checks["redis"]; got.Status != health.StatusError || got.Error != "connection refused" {
+		t.Fatalf("redis = %+v, want the error", got)
+	}
+	if got := report.Checks["telegram"]; got.Status != health.StatusError || got.Error != "timed out" {
+		t.Fatalf("telegram = %+v, want timed out", got)
+	}
+}
+
+func TestOKWithoutFailures(t *testing.T) {
+	c := health.New(time.Second)
+	c.Add("postgres", func(ctx context.Context) error { return nil })
+
+	if report := c.Run(context.Background()); !report.OK() || len(report.Checks) != 1 {
+		t.Fatalf("expected ok report, got %+v", report)
+	}
+}
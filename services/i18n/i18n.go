@@ -0,0 +1,165 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Catalog of the messages of a language. Messages take {0}, {1}... params, which must appear in order.
+type Catalog struct {
+	Locale   locales.Translator
+	Messages map[string]string
+	// Plurals are messages with a {0} count, by the plural rules of the locale
+	Plurals map[string]map[locales.PluralRule]string
+}
+
+// Bundle of catalogs, falling back to the first for unsupported languages
+type Bundle struct {
+	uni       *ut.UniversalTranslator
+	languages []string
+}
+
+// New bundle, returning an error if a catalog is missing messages or plural rules of the first
+func New(fallback Catalog, catalogs ...Catalog) (*Bundle, error) {
+	all := append([]Catalog{fallback}, catalogs...)
+	supported := make([]locales.Translator, len(all))
+	for i, catalog := range all {
+		supported[i] = catalog.Locale
+	}
+	b := &Bundle{uni: ut.New(fallback.Locale, supported...)}
+
+	for _, catalog := range all {
+		language := catalog.Locale.Locale()
+		trans, _ := b.uni.GetTranslator(language)
+		for key, text := range fallback.Messages {
+			translated, ok := catalog.Messages[key]
+			if !ok {
+				return nil, fmt.Errorf("%s: missing message %s", language, key)
+			}
+			if err := checkParams(translated, countParams(text)); err != nil {
+				return nil, fmt.Errorf("%s: message %s: %w", language, key, err)
+			}
+			if err := trans.Add(key, translated, false); err != nil {
+				return nil, fmt.Errorf("%s: message %s: %w", language, key, err)
+			}
+		}
+		for key := range fallback.Plurals {
+			rules, ok := catalog.Plurals[key]
+			if !ok {
+				return nil, fmt.Errorf("%s: missing plural %s", language, key)
+			}
+			for rule, text := range rules {
+				if err := trans.AddCardinal(key, text, rule, false); err != nil {
+					return nil, fmt.Errorf("%s: plural %s: %w", language, key, err)
+				}
+			}
+		}
+		if err := trans.VerifyTranslations(); err != nil {
+			return nil, fmt.Errorf("%s: %w", language, err)
+		}
+		b.languages = append(b.languages, language)
+	}
+	return b, nil
+}
+
+// Languages supported, starting with the fallback
+func (b *Bundle) Languages() []string {
+	return b.languages
+}
+
+// Supports returns true if language has a catalog, see Normalize
+func (b *Bundle) Supports(language string) bool {
+	_, ok := b.uni.GetTranslator(Normalize(language))
+	return ok
+}
+
+// Translator for language, or the fallback if it is not supported
+func (b *Bundle) Translator(language string) Translator {
+	trans, ok := b.uni.GetTranslator(Normalize(language))
+	if !ok {
+		trans = b.uni.GetFallback()
+	}
+	return Translator{trans}
+}
+
+// Normalize a telegram language_code such as zh-hans to its base language
+func Normalize(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	return language
+}
+
+// Translator of a language
+type Translator struct {
+	trans ut.Translator
+}
+
+// Language of the translator
+func (t Translator) Language() string {
+	return t.trans.Locale()
+}
+
+// T translates the message key with params, returning the key if it is unknown
+func (t Translator) T(key string, params ...interface{}) string {
+	strs := make([]string, len(params))
+	for i, param := range params {
+		strs[i] = fmt.Sprint(param)
+	}
+	text, err := t.trans.T(key, strs...)
+	if err != nil {
+		return key
+	}
+	return text
+}
+
+// C translates the plural key for count, returning the key if it is unknown
+func (t Translator) C(key string, count int) string {
+	text, err := t.trans.C(key, float64(count), 0, t.trans.FmtNumber(float64(count), 0))
+	if err != nil {
+		return key
+	}
+	return text
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying t
+func WithContext(ctx context.Context, t Translator) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the translator carried by ctx, or fallback if there is none
+func FromContext(ctx context.Context, fallback Translator) Translator {
+	if t, ok := ctx.Value(contextKey{}).(Translator); ok {
+		return t
+	}
+	return fallback
+}
+
+// countParams in text
+func countParams(text string) int {
+	return strings.Count(text, "{")
+}
+
+// checkParams makes sure text has params {0} to {n-1} in order, as translators substitute them in order
+func checkParams(text string, n int) error {
+	if got := countParams(text); got != n {
+		return fmt.Errorf("has %d params, want %d", got, n)
+	}
+	last := -1
+	for i := 0; i < n; i++ {
+		idx := strings.Index(text, "{"+strconv.Itoa(i)+"}")
+		if idx < last {
+			return fmt.Errorf("param {%d} is out of order", i)
+		}
+		last = idx
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+)
+
+func catalogs() (Catalog, Catalog) {
+	english := Catalog{
+		Locale:   en.New(),
+		Messages: map[string]string{"greet": "Hello {0}, welcome to {1}"},
+		Plurals: map[string]map[locales.PluralRule]string{
+			"items": {locales.PluralRuleOne: "{0} item", locales.PluralRuleOther: "{0} items"},
+		},
+	}
+	chinese := Catalog{
+		Locale:   zh.New(),
+		Messages: map[string]string{"greet": "{0}你好，欢迎来到{1}"},
+		Plurals: map[string]map[locales.PluralRule]string{
+			"items": {locales.PluralRuleOther: "{0} 份"},
+		},
+	}
+	return english, chinese
+}
+
+func TestTranslator(t *testing.T) {
+	english, chinese := catalogs()
+	b, err := New(english, chinese)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		language string
+		want     string
+		items    string
+	}{
+		{"en", "Hello Alice, welcome to Kopitiam", "1 item"},
+		{"zh-hans", "Alice你好，欢迎来到Kopitiam", "1 份"},
+		{"fr", "Hello Alice, welcome to Kopitiam", "1 item"},
+		{"", "Hello Alice, welcome to Kopitiam", "1 item"},
+	}
+	for _, tt := range tests {
+		tr := b.Translator(tt.language)
+		if got := tr.T("greet", "Alice", "Kopitiam"); got != tt.want {
+			t.Errorf("%q T = %q, want %q", tt.language, got, tt.want)
+		}
+		if got := tr.C("items", 1); got != tt.items {
+			t.Errorf("%q C = %q, want %q", tt.language, got, tt.items)
+		}
+	}
+	if got := b.Translator("en").C("items", 1200); got != "1,200 items" {
+		t.Errorf("C = %q, want 1,200 items", got)
+	}
+	if got := b.Translator("en").T("missing"); got != "missing" {
+		t.Errorf("T of missing key = %q, want the key", got)
+	}
+
+	ctx := WithContext(context.Background(), b.Translator("zh"))
+	if got := FromContext(ctx, b.Translator("en")).Language(); got != "zh" {
+		t.Errorf("FromContext language = %q, want zh", got)
+	}
+}
+
+func TestNewRejectsInvalidCatalogs(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Catalog)
+		want   string
+	}{
+		{"missing message", func(c *Catalog) { c.Messages = map[string]string{} }, "missing message greet"},
+		{"missing param", func(c *Catalog) { c.Messages["greet"] = "{0}你好" }, "has 1 params, want 2"},
+		{"params out of order", func(c *Catalog) { c.Messages["greet"] = "欢迎来到{1}，{0}" }, "out of order"},
+		{"missing plural", func(c *Catalog) { c.Plurals = nil }, "missing plural items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			english, chinese := catalogs()
+			tt.modify(&chinese)
+			_, err := New(english, chinese)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("New err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
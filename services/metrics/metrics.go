@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "orderbot"
+
+// command and job outcomes
+const (
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+// activeOrdersTimeout bounds the query run on every scrape
+const activeOrdersTimeout = 5 * time.Second
+
+var (
+	// Commands handled, by command name and outcome
+	Commands = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commands_total",
+		Help:      "Bot commands handled, by command and outcome.",
+	}, []string{"command", "outcome"})
+
+	// WebhookDuration of webhook requests, which only queue the update
+	WebhookDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_request_duration_seconds",
+		Help:      "Time taken to accept webhook updates, by response status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status"})
+
+	// TelegramDuration of each bot api request, retries are observed separately
+	TelegramDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "telegram_request_duration_seconds",
+		Help:      "Telegram bot api request latency, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// TelegramErrors returned after retries, by bot api method
+	TelegramErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_errors_total",
+		Help:      "Telegram bot api calls that failed after retries, by method.",
+	}, []string{"method"})
+
+	// Jobs run, by job name and outcome
+	Jobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Scheduled jobs run, by job and outcome.",
+	}, []string{"job", "outcome"})
+)
+
+// Outcome of err
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeOK
+}
+
+// ObserveJob run of the job
+func ObserveJob(name string, err error) {
+	Jobs.WithLabelValues(name, Outcome(err)).Inc()
+}
+
+// RegisterActiveOrders gauge, count is called on every scrape
+func RegisterActiveOrders(count func(ctx context.Context) (int64, error)) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_orders",
+		Help:      "Orders currently taking items.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), activeOrdersTimeout)
+		defer cancel()
+		n, err := count(ctx)
+		if err != nil {
+			// reported as a gap rather than 0 orders
+			return math.NaN()
+		}
+		return float64(n)
+	})
+}
+
+// Handler serves the metrics in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gpng/order-bot/sqlc/schemas"
+	"github.com/pressly/goose/v3"
+)
+
+// lockID is the postgres advisory lock held while migrating, so concurrently starting instances take turns
+const lockID int64 = 7305948201
+
+// migrations are at the root of the embedded fs
+const dir = "."
+
+func init() {
+	goose.SetBaseFS(schemas.FS)
+	if err := goose.SetDialect("postgres"); err != nil {
+		panic(err)
+	}
+}
+
+// Up applies all pending migrations
+func Up(ctx context.Context, db *sql.DB) error {
+	return withLock(ctx, db, func() error {
+		return goose.Up(db, dir)
+	})
+}
+
+// Down rolls back the latest migration
+func Down(ctx context.Context, db *sql.DB) error {
+	return withLock(ctx, db, func() error {
+		return goose.Down(db, dir)
+	})
+}
+
+// Status logs the applied and pending migrations
+func Status(ctx context.Context, db *sql.DB) error {
+	return goose.Status(db, dir)
+}
+
+// withLock runs fn while holding the advisory lock. The lock belongs to a session, so it is taken and
+// released on a dedicated connection while goose migrates on others.
+func withLock(ctx context.Context, db *sql.DB, fn func() error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	return fn()
+}
@@ -11,10 +11,14 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/postgres" // required for postgres dbs
 )
 
-// New db connection and trigger migrations
-func New(dbHost string, dbUser string, dbName string, dbPassword string) (*sql.DB, error) {
+// New db connection and trigger migrations.
+// Sessions are in timeZone when given, so NOW() in TIMESTAMP columns is its wall clock like the times the app writes.
+func New(dbHost string, dbUser string, dbName string, dbPassword string, timeZone string) (*sql.DB, error) {
 	// connection string
 	dbURI := fmt.Sprintf("host=%s user=%s dbname=%s sslmode=disable password=%s", dbHost, dbUser, dbName, dbPassword)
+	if timeZone != "" {
+		dbURI += " timezone=" + timeZone
+	}
 	log.Printf("db connection string: %s", dbURI)
 
 	db, err := sql.Open("postgres", dbURI)
@@ -0,0 +1,60 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/gpng/order-bot/services/migrate"
+	"github.com/gpng/order-bot/services/postgres"
+)
+
+// defaults match the app config, the database is separate as it is wiped
+const (
+	defaultDbHost     = "localhost"
+	defaultDbUser     = "postgres"
+	defaultDbPassword = "postgres"
+	defaultDbName     = "order-bot-test"
+)
+
+// New connects to TEST_DB_NAME and wipes and migrates it, skipping the test if postgres is unavailable.
+// DB_HOST, DB_USER and DB_PASSWORD are read like the app config.
+func New(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := postgres.New(
+		getenv("DB_HOST", defaultDbHost),
+		getenv("DB_USER", defaultDbUser),
+		getenv("TEST_DB_NAME", defaultDbName),
+		getenv("DB_PASSWORD", defaultDbPassword),
+		"",
+	)
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	reset(t, db)
+
+	return db
+}
+
+// reset drops everything and applies the embedded migrations
+func reset(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;")
+	if err != nil {
+		t.Fatalf("failed to reset database: %v", err)
+	}
+	if err := migrate.Up(context.Background(), db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+}
+
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gpng/order-bot/services/clock"
+)
+
+// Memory schedules jobs in memory and runs them when its fake clock is advanced, for tests
+type Memory struct {
+	clock *clock.Fake
+
+	mu   sync.Mutex
+	seq  int
+	jobs map[Handle]memoryJob
+}
+
+type memoryJob struct {
+	at  time.Time
+	seq int
+	job Job
+}
+
+var _ Scheduler = (*Memory)(nil)
+
+// NewMemory scheduler driven by c
+func NewMemory(c *clock.Fake) *Memory {
+	return &Memory{clock: c, jobs: map[Handle]memoryJob{}}
+}
+
+// Schedule a unique job, returning ErrAlreadyScheduled if an identical job is scheduled
+func (m *Memory) Schedule(ctx context.Context, name string, at time.Time, args map[string]interface{}) (Handle, error) {
+	// round trip args through json like a real backend, so numbers come back as float64
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return "", err
+	}
+	job := Job{Name: name, Args: decoded}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, scheduled := range m.jobs {
+		if scheduled.job.Name == name && reflect.DeepEqual(scheduled.job.Args, job.Args) {
+			return "", ErrAlreadyScheduled
+		}
+	}
+	m.seq++
+	handle := Handle(fmt.Sprintf("mem-%d", m.seq))
+	m.jobs[handle] = memoryJob{at: at, seq: m.seq, job: job}
+	return handle, nil
+}
+
+// Cancel removes the job
+func (m *Memory) Cancel(ctx context.Context, handle Handle) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.jobs, handle)
+	return nil
+}
+
+// Reschedule removes the job and schedules it again at
+func (m *Memory) Reschedule(ctx context.Context, handle Handle, at time.Time) (Handle, error) {
+	m.mu.Lock()
+	scheduled, ok := m.jobs[handle]
+	delete(m.jobs, handle)
+	m.mu.Unlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+	return m.Schedule(ctx, scheduled.job.Name, at, scheduled.job.Args)
+}
+
+// List jobs ordered by time
+func (m *Memory) List(ctx context.Context) ([]Scheduled, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]memoryJob, 0, len(m.jobs))
+	handles := make(map[int]Handle, len(m.jobs))
+	for handle, scheduled := range m.jobs {
+		jobs = append(jobs, scheduled)
+		handles[scheduled.seq] = handle
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].at.Equal(jobs[j].at) {
+			return jobs[i].at.Before(jobs[j].at)
+		}
+		return jobs[i].seq < jobs[j].seq
+	})
+
+	scheduled := make([]Scheduled, len(jobs))
+	for i, job := range jobs {
+		scheduled[i] = Scheduled{Handle: handles[job.seq], At: job.at, Job: job.job}
+	}
+	return scheduled, nil
+}
+
+// Pending returns the number of scheduled jobs that have not run
+func (m *Memory) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.jobs)
+}
+
+// Advance moves the clock forward by d, running jobs that become due in order with run.
+// The clock is set to each job's time before it runs, and running stops at the first error.
+func (m *Memory) Advance(ctx context.Context, d time.Duration, run RunFunc) error {
+	until := m.clock.Now().Add(d)
+	for {
+		handle, scheduled, ok := m.nextDue(until)
+		if !ok {
+			break
+		}
+		if scheduled.at.After(m.clock.Now()) {
+			m.clock.Set(scheduled.at)
+		}
+		if err := run(ctx, scheduled.job); err != nil {
+			return fmt.Errorf("job %s %s: %w", handle, scheduled.job.Name, err)
+		}
+	}
+	m.clock.Set(until)
+	return nil
+}
+
+// nextDue removes and returns the earliest job due by until
+func (m *Memory) nextDue(until time.Time) (Handle, memoryJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var (
+		next     Handle
+		earliest memoryJob
+		found    bool
+	)
+	for handle, scheduled := range m.jobs {
+		if scheduled.at.After(until) {
+			continue
+		}
+		if !found || scheduled.at.Before(earliest.at) || (scheduled.at.Equal(earliest.at) && scheduled.seq < earliest.seq) {
+			next, earliest, found = handle, scheduled, true
+		}
+	}
+	if found {
+		delete(m.jobs, next)
+	}
+	return next, earliest, found
+}
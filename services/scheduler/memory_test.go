@@ -0,0 +1,89 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/services/clock"
+	"github.com/gpng/order-bot/services/scheduler"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	s := scheduler.NewMemory(c)
+
+	type run struct {
+		at    time.Time
+		order int64
+	}
+	var runs []run
+	record := func(ctx context.Context, job scheduler.Job) error {
+		runs = append(runs, run{at: c.Now(), order: job.ArgInt64("order")})
+		return nil
+	}
+
+	late, err := s.Schedule(ctx, "job", start.Add(2*time.Hour), map[string]interface{}{"order": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Schedule(ctx, "job", start.Add(time.Hour), map[string]interface{}{"order": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Schedule(ctx, "job", start, map[string]interface{}{"order": 1}); !errors.Is(err, scheduler.ErrAlreadyScheduled) {
+		t.Fatalf("expected %v, got %v", scheduler.ErrAlreadyScheduled, err)
+	}
+	cancelled, err := s.Schedule(ctx, "job", start.Add(30*time.Minute), map[string]interface{}{"order": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel(ctx, cancelled); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduled, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 2 || scheduled[0].Job.ArgInt64("order") != 1 || scheduled[1].Handle != late {
+		t.Fatalf("expected jobs ordered by time, got %+v", scheduled)
+	}
+
+	if err := s.Advance(ctx, 59*time.Minute, record); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 0 {
+		t.Fatalf("expected no jobs to run, got %+v", runs)
+	}
+
+	late, err = s.Reschedule(ctx, late, start.Add(90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Advance(ctx, 2*time.Hour, record); err != nil {
+		t.Fatal(err)
+	}
+	want := []run{{start.Add(time.Hour), 1}, {start.Add(90 * time.Minute), 2}}
+	if len(runs) != len(want) {
+		t.Fatalf("expected runs %+v, got %+v", want, runs)
+	}
+	for i := range want {
+		if !runs[i].at.Equal(want[i].at) || runs[i].order != want[i].order {
+			t.Fatalf("expected runs %+v, got %+v", want, runs)
+		}
+	}
+	if now := c.Now(); !now.Equal(start.Add(59*time.Minute + 2*time.Hour)) {
+		t.Fatalf("expected clock to advance to the end, got %v", now)
+	}
+
+	if err := s.Cancel(ctx, late); err != nil {
+		t.Fatalf("expected cancelling a job that ran to succeed, got %v", err)
+	}
+	if _, err := s.Reschedule(ctx, late, start); !errors.Is(err, scheduler.ErrNotFound) {
+		t.Fatalf("expected %v, got %v", scheduler.ErrNotFound, err)
+	}
+}
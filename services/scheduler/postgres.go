@@ -0,0 +1,294 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gpng/order-bot/services/clock"
+	"go.uber.org/zap"
+)
+
+// postgres scheduler settings
+const (
+	postgresPollInterval = time.Second
+	postgresBatchSize    = 10
+	// claimed jobs are retried by any instance once their lease expires, in case the claiming instance died
+	postgresLease        = 5 * time.Minute
+	postgresMaxAttempts  = 3
+	postgresRetryBackoff = 10 * time.Second
+	postgresHandlePrefix = "pg:"
+)
+
+// Postgres schedules jobs in the scheduled_jobs table, jobs are run by Run which is safe to call from several instances
+type Postgres struct {
+	db     *sql.DB
+	clock  clock.Clock
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	lastPoll time.Time
+}
+
+var _ Scheduler = (*Postgres)(nil)
+
+// NewPostgres scheduler
+func NewPostgres(db *sql.DB, c clock.Clock, logger *zap.Logger) *Postgres {
+	return &Postgres{db: db, clock: c, logger: logger}
+}
+
+// Schedule a unique job, returning ErrAlreadyScheduled if an identical job is scheduled
+func (p *Postgres) Schedule(ctx context.Context, name string, at time.Time, args map[string]interface{}) (Handle, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+
+	var id int64
+	err = p.db.QueryRowContext(ctx, `
+INSERT INTO scheduled_jobs (name, args, run_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (name, args) WHERE failed_at IS NULL DO NOTHING
+RETURNING id`, name, string(data), at.Unix()).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAlreadyScheduled
+	}
+	if err != nil {
+		return "", err
+	}
+	return postgresHandle(id), nil
+}
+
+// Cancel deletes the scheduled job
+func (p *Postgres) Cancel(ctx context.Context, handle Handle) error {
+	id, err := parsePostgresHandle(handle)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, `DELETE FROM scheduled_jobs WHERE id = $1`, id)
+	return err
+}
+
+// Reschedule moves the job to at, resetting its attempts. The handle does not change.
+func (p *Postgres) Reschedule(ctx context.Context, handle Handle, at time.Time) (Handle, error) {
+	id, err := parsePostgresHandle(handle)
+	if err != nil {
+		return "", err
+	}
+	res, err := p.db.ExecContext(ctx, `
+UPDATE scheduled_jobs
+SET run_at = $2, attempts = 0, locked_until = NULL
+WHERE id = $1
+AND failed_at IS NULL`, id, at.Unix())
+	if err != nil {
+		return "", err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", ErrNotFound
+	}
+	return handle, nil
+}
+
+// List jobs that have not failed, including jobs waiting to be retried
+func (p *Postgres) List(ctx context.Context) ([]Scheduled, error) {
+	rows, err := p.db.QueryContext(ctx, `
+SELECT id, name, args, run_at FROM scheduled_jobs
+WHERE failed_at IS NULL
+ORDER BY run_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scheduled []Scheduled
+	for rows.Next() {
+		var (
+			id    int64
+			runAt int64
+			args  []byte
+			job   Job
+		)
+		if err := rows.Scan(&id, &job.Name, &args, &runAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(args, &job.Args); err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, Scheduled{Handle: postgresHandle(id), At: time.Unix(runAt, 0), Job: job})
+	}
+	return scheduled, rows.Err()
+}
+
+// Run polls for due jobs and runs them with run until ctx is done, finishing claimed jobs before it returns.
+// Failed jobs are retried with exponential backoff, and kept with their error after the last attempt.
+func (p *Postgres) Run(ctx context.Context, run RunFunc) {
+	ticker := time.NewTicker(postgresPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := p.runDue(ctx, run)
+			if err != nil && ctx.Err() == nil {
+				p.logger.Error("failed to claim scheduled jobs", zap.Error(err))
+			}
+			if err != nil || n < postgresBatchSize {
+				break
+			}
+		}
+		p.mu.Lock()
+		p.lastPoll = p.clock.Now()
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Heartbeat is when Run last polled for due jobs, zero until it first polls
+func (p *Postgres) Heartbeat() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastPoll
+}
+
+type postgresJob struct {
+	id       int64
+	runAt    int64
+	attempts int
+	job      Job
+}
+
+// runDue claims a batch of due jobs and runs them, returning the number claimed.
+// Claimed jobs run even if ctx is done so they aren't left locked until their lease expires.
+func (p *Postgres) runDue(ctx context.Context, run RunFunc) (int, error) {
+	jobs, err := p.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, job := range jobs {
+		p.runJob(context.Background(), run, job)
+	}
+	return len(jobs), nil
+}
+
+// claim leases due jobs, rows locked by other instances are skipped rather than waited on
+func (p *Postgres) claim(ctx context.Context) ([]postgresJob, error) {
+	now := p.clock.Now()
+	rows, err := p.db.QueryContext(ctx, `
+UPDATE scheduled_jobs
+SET attempts = attempts + 1, locked_until = $2
+WHERE id IN (
+  SELECT id FROM scheduled_jobs
+  WHERE run_at <= $1
+  AND failed_at IS NULL
+  AND (locked_until IS NULL OR locked_until <= $1)
+  ORDER BY run_at, id
+  LIMIT $3
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, name, args, run_at, attempts`, now.Unix(), now.Add(postgresLease).Unix(), postgresBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []postgresJob
+	for rows.Next() {
+		var (
+			job  postgresJob
+			args []byte
+		)
+		if err := rows.Scan(&job.id, &job.job.Name, &args, &job.runAt, &job.attempts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(args, &job.job.Args); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not keep the subquery's order
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].runAt != jobs[j].runAt {
+			return jobs[i].runAt < jobs[j].runAt
+		}
+		return jobs[i].id < jobs[j].id
+	})
+	return jobs, nil
+}
+
+// runJob deletes the job once it succeeds, or releases it to be retried later
+func (p *Postgres) runJob(ctx context.Context, run RunFunc, job postgresJob) {
+	l := p.logger.With(zap.Int64("job_id", job.id), zap.String("job", job.job.Name), zap.Int("attempt", job.attempts))
+
+	err := runRecovered(ctx, run, job.job)
+	if err == nil {
+		if _, err := p.db.ExecContext(ctx, `DELETE FROM scheduled_jobs WHERE id = $1`, job.id); err != nil {
+			l.Error("failed to delete completed job", zap.Error(err))
+		}
+		return
+	}
+
+	now := p.clock.Now()
+	if job.attempts >= postgresMaxAttempts {
+		l.Error("scheduled job failed, giving up", zap.Error(err))
+		_, err = p.db.ExecContext(ctx, `
+UPDATE scheduled_jobs
+SET failed_at = $2, last_error = $3, locked_until = NULL
+WHERE id = $1`, job.id, now.Unix(), err.Error())
+	} else {
+		l.Warn("scheduled job failed, retrying", zap.Error(err))
+		retryAt := now.Add(postgresRetryBackoff << (job.attempts - 1))
+		_, err = p.db.ExecContext(ctx, `
+UPDATE scheduled_jobs
+SET run_at = $2, last_error = $3, locked_until = NULL
+WHERE id = $1`, job.id, retryAt.Unix(), err.Error())
+	}
+	if err != nil {
+		l.Error("failed to release job", zap.Error(err))
+	}
+}
+
+// runRecovered runs the job, turning a panic into an error so the job is retried
+func runRecovered(ctx context.Context, run RunFunc, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx, job)
+}
+
+func postgresHandle(id int64) Handle {
+	return Handle(postgresHandlePrefix + strconv.FormatInt(id, 10))
+}
+
+func parsePostgresHandle(handle Handle) (int64, error) {
+	if !strings.HasPrefix(string(handle), postgresHandlePrefix) {
+		return 0, fmt.Errorf("%w %q", ErrInvalidHandle, handle)
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(string(handle), postgresHandlePrefix), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidHandle, handle)
+	}
+	return id, nil
+}
@@ -0,0 +1,153 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/services/clock"
+	"github.com/gpng/order-bot/services/postgres/postgrestest"
+	"github.com/gpng/order-bot/services/scheduler"
+	"go.uber.org/zap/zaptest"
+)
+
+const postgresTestTimeout = 10 * time.Second
+
+func TestPostgres(t *testing.T) {
+	ctx := context.Background()
+	db := postgrestest.New(t)
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	s := scheduler.NewPostgres(db, c, zaptest.NewLogger(t))
+
+	due, err := s.Schedule(ctx, "job", start.Add(time.Minute), map[string]interface{}{"order": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Schedule(ctx, "job", start, map[string]interface{}{"order": 1}); !errors.Is(err, scheduler.ErrAlreadyScheduled) {
+		t.Fatalf("expected %v, got %v", scheduler.ErrAlreadyScheduled, err)
+	}
+	cancelled, err := s.Schedule(ctx, "job", start.Add(time.Minute), map[string]interface{}{"order": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel(ctx, cancelled); err != nil {
+		t.Fatal(err)
+	}
+	later, err := s.Schedule(ctx, "job", start.Add(time.Minute), map[string]interface{}{"order": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Reschedule(ctx, later, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel(ctx, "1:abc"); !errors.Is(err, scheduler.ErrInvalidHandle) {
+		t.Fatalf("expected %v, got %v", scheduler.ErrInvalidHandle, err)
+	}
+
+	// the first attempt fails to check the job is retried after the backoff
+	failed := make(chan struct{})
+	ran := make(chan int64, 10)
+	attempts := 0
+	run := func(ctx context.Context, job scheduler.Job) error {
+		attempts++
+		if attempts == 1 {
+			close(failed)
+			return errors.New("failed")
+		}
+		ran <- job.ArgInt64("order")
+		return nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.Advance(time.Minute)
+	go s.Run(runCtx, run)
+
+	select {
+	case <-failed:
+	case <-time.After(postgresTestTimeout):
+		t.Fatalf("timed out waiting for job")
+	}
+	select {
+	case order := <-ran:
+		t.Fatalf("expected order %d to wait for the retry backoff", order)
+	case <-time.After(2 * time.Second):
+	}
+
+	c.Advance(time.Minute)
+	select {
+	case order := <-ran:
+		if order != 1 {
+			t.Fatalf("expected order 1 to run, got %d", order)
+		}
+	case <-time.After(postgresTestTimeout):
+		t.Fatalf("timed out waiting for retry")
+	}
+
+	// the job is deleted once it runs, so it can be scheduled again
+	if err := s.Cancel(ctx, due); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Schedule(ctx, "job", start, map[string]interface{}{"order": 1}); err != nil {
+		t.Fatalf("expected completed job to be removed, got %v", err)
+	}
+}
+
+func TestPostgresRunsJobsOnceAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	db := postgrestest.New(t)
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	logger := zaptest.NewLogger(t)
+
+	const jobs = 50
+	s := scheduler.NewPostgres(db, c, logger)
+	for i := 0; i < jobs; i++ {
+		if _, err := s.Schedule(ctx, "job", start, map[string]interface{}{"order": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mu   sync.Mutex
+		runs = map[int64]int{}
+		done = make(chan struct{})
+	)
+	run := func(ctx context.Context, job scheduler.Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs[job.ArgInt64("order")]++
+		if len(runs) == jobs {
+			select {
+			case <-done:
+			default:
+				close(done)
+			}
+		}
+		return nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		go scheduler.NewPostgres(db, c, logger).Run(runCtx, run)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(postgresTestTimeout):
+		t.Fatalf("timed out waiting for jobs")
+	}
+	// let any duplicate claims finish before checking
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	for order, n := range runs {
+		if n != 1 {
+			t.Fatalf("expected order %d to run once, ran %d times", order, n)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// scheduler errors
+var (
+	ErrAlreadyScheduled = errors.New("job already scheduled")
+	ErrNotFound         = errors.New("scheduled job not found")
+	// ErrInvalidHandle is returned for handles that were not created by the scheduler, such as another backend's
+	ErrInvalidHandle = errors.New("invalid handle")
+)
+
+// Handle is an opaque reference to a scheduled job, safe to store as text
+type Handle string
+
+// Scheduler runs jobs at a later time
+type Scheduler interface {
+	// Schedule job name with args to run at
+	Schedule(ctx context.Context, name string, at time.Time, args map[string]interface{}) (Handle, error)
+	// Cancel a scheduled job, cancelling a job that already ran or does not exist is not an error
+	Cancel(ctx context.Context, handle Handle) error
+	// Reschedule a scheduled job to run at, returning its new handle
+	Reschedule(ctx context.Context, handle Handle, at time.Time) (Handle, error)
+	// List pending jobs, ordered by time
+	List(ctx context.Context) ([]Scheduled, error)
+}
+
+// Scheduled job returned by List
+type Scheduled struct {
+	Handle Handle
+	At     time.Time
+	Job    Job
+}
+
+// Job passed to RunFunc when it is due
+type Job struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// RunFunc runs due jobs
+type RunFunc func(ctx context.Context, job Job) error
+
+// ArgInt64 returns an int64 argument, which is decoded from json as a float64 by some backends
+func (j Job) ArgInt64(key string) int64 {
+	switch v := j.Args[key].(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case json.Number:
+		i, _ := v.Int64()
+		return i
+	}
+	return 0
+}
+
+// ArgBool returns a bool argument
+func (j Job) ArgBool(key string) bool {
+	b, _ := j.Args[key].(bool)
+	return b
+}
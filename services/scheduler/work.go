@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocraft/work"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Work schedules jobs with gocraft/work, jobs are run by a work.WorkerPool in the same namespace
+type Work struct {
+	namespace string
+	pool      *redis.Pool
+	enqueuer  *work.Enqueuer
+	client    *work.Client
+}
+
+var _ Scheduler = (*Work)(nil)
+
+// NewWork scheduler in the redis namespace
+func NewWork(namespace string, pool *redis.Pool) *Work {
+	return &Work{
+		namespace: namespace,
+		pool:      pool,
+		enqueuer:  work.NewEnqueuer(namespace, pool),
+		client:    work.NewClient(namespace, pool),
+	}
+}
+
+// Schedule a unique job, returning ErrAlreadyScheduled if an identical job is scheduled
+func (w *Work) Schedule(ctx context.Context, name string, at time.Time, args map[string]interface{}) (Handle, error) {
+	secondsFromNow := int64(time.Until(at) / time.Second)
+	if secondsFromNow < 0 {
+		secondsFromNow = 0
+	}
+	job, err := w.enqueuer.EnqueueUniqueIn(name, secondsFromNow, args)
+	if err != nil {
+		return "", err
+	}
+	if job == nil {
+		return "", ErrAlreadyScheduled
+	}
+	return workHandle(job.RunAt, job.ID), nil
+}
+
+// Cancel deletes the scheduled job
+func (w *Work) Cancel(ctx context.Context, handle Handle) error {
+	runAt, id, err := parseWorkHandle(handle)
+	if err != nil {
+		return err
+	}
+	err = w.client.DeleteScheduledJob(runAt, id)
+	if err != nil && !errors.Is(err, work.ErrNotDeleted) {
+		return err
+	}
+	return nil
+}
+
+// Reschedule deletes the scheduled job and schedules it again with the same name and args
+func (w *Work) Reschedule(ctx context.Context, handle Handle, at time.Time) (Handle, error) {
+	job, err := w.scheduledJob(handle)
+	if err != nil {
+		return "", err
+	}
+	if err := w.Cancel(ctx, handle); err != nil {
+		return "", err
+	}
+	return w.Schedule(ctx, job.Name, at, job.Args)
+}
+
+// List jobs in the scheduled set, jobs that are due are moved out of it to be run
+func (w *Work) List(ctx context.Context) ([]Scheduled, error) {
+	conn := w.pool.Get()
+	defer conn.Close()
+	values, err := redis.Values(conn.Do("ZRANGE", w.namespace+":scheduled", 0, -1, "WITHSCORES"))
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduled []Scheduled
+	for len(values) > 0 {
+		var (
+			raw   []byte
+			runAt int64
+		)
+		values, err = redis.Scan(values, &raw, &runAt)
+		if err != nil {
+			return nil, err
+		}
+		var job work.Job
+		if err := json.Unmarshal(raw, &job); err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, Scheduled{
+			Handle: workHandle(runAt, job.ID),
+			At:     time.Unix(runAt, 0),
+			Job:    Job{Name: job.Name, Args: job.Args},
+		})
+	}
+	return scheduled, nil
+}
+
+// scheduledJob looks up the job in the scheduled set, which is scored by run at
+func (w *Work) scheduledJob(handle Handle) (*work.Job, error) {
+	runAt, id, err := parseWorkHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := w.pool.Get()
+	defer conn.Close()
+	raws, err := redis.ByteSlices(conn.Do("ZRANGEBYSCORE", w.namespace+":scheduled", runAt, runAt))
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range raws {
+		var job work.Job
+		if err := json.Unmarshal(raw, &job); err != nil {
+			return nil, err
+		}
+		if job.ID == id {
+			return &job, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// work handles are "<run at>:<job id>", as deleting a scheduled job needs both
+func workHandle(runAt int64, id string) Handle {
+	return Handle(fmt.Sprintf("%d:%s", runAt, id))
+}
+
+func parseWorkHandle(handle Handle) (int64, string, error) {
+	split := strings.SplitN(string(handle), ":", 2)
+	if len(split) != 2 {
+		return 0, "", fmt.Errorf("%w %q", ErrInvalidHandle, handle)
+	}
+	runAt, err := strconv.ParseInt(split[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("%w %q", ErrInvalidHandle, handle)
+	}
+	return runAt, split[1], nil
+}
@@ -0,0 +1,84 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Component is a long running part of the app
+type Component struct {
+	Name string
+	// Run blocks until ctx is done or the component fails, returning nil when stopped by ctx
+	Run func(ctx context.Context) error
+	// Stop is optional and drains the component before ctx is done, it is called after Run's ctx is done
+	Stop func(ctx context.Context) error
+}
+
+// Supervisor runs components until one fails or it is stopped, then stops them in order
+type Supervisor struct {
+	logger     *zap.Logger
+	components []Component
+}
+
+// New supervisor of components, which are stopped in the given order
+func New(logger *zap.Logger, components ...Component) *Supervisor {
+	return &Supervisor{logger, components}
+}
+
+// Run starts the components and blocks until ctx is done or a component fails.
+// Every component is then stopped in order, all within drainTimeout, and the first component error is returned.
+func (s *Supervisor) Run(ctx context.Context, drainTimeout time.Duration) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		stopped  = make([]chan struct{}, len(s.components))
+	)
+	for i, c := range s.components {
+		stopped[i] = make(chan struct{})
+		go func(c Component, stopped chan struct{}) {
+			defer close(stopped)
+			err := c.Run(runCtx)
+			if err != nil {
+				s.logger.Error("component failed", zap.String("component", c.Name), zap.Error(err))
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", c.Name, err)
+				}
+				mu.Unlock()
+			}
+			// a component returning early stops the rest
+			cancel()
+		}(c, stopped[i])
+	}
+
+	<-runCtx.Done()
+	s.logger.Info("stopping components")
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer drainCancel()
+	for i, c := range s.components {
+		l := s.logger.With(zap.String("component", c.Name))
+		if c.Stop != nil {
+			if err := c.Stop(drainCtx); err != nil {
+				l.Error("failed to stop component", zap.Error(err))
+			}
+		}
+		select {
+		case <-stopped[i]:
+			l.Info("stopped component")
+		case <-drainCtx.Done():
+			l.Error("component did not stop before the drain deadline")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return firstErr
+}
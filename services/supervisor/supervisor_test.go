@@ -0,0 +1,98 @@
+package supervisor_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/services/supervisor"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestStopsInOrder(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	component := func(name string) supervisor.Component {
+		return supervisor.Component{
+			Name: name,
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				record(name)
+				return nil
+			},
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := supervisor.New(zaptest.NewLogger(t), component("http"), component("updates"), component("jobs"))
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, time.Second) }()
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := []string{"http", "updates", "jobs"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected components to stop in order %v, got %v", want, events)
+	}
+}
+
+func TestFailureStopsComponents(t *testing.T) {
+	failed := errors.New("failed")
+	stopped := make(chan struct{})
+	s := supervisor.New(zaptest.NewLogger(t),
+		supervisor.Component{
+			Name: "waiting",
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				close(stopped)
+				return nil
+			},
+		},
+		supervisor.Component{
+			Name: "failing",
+			Run:  func(ctx context.Context) error { return failed },
+		},
+	)
+
+	if err := s.Run(context.Background(), time.Second); !errors.Is(err, failed) {
+		t.Fatalf("expected %v, got %v", failed, err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Fatalf("expected waiting component to be stopped")
+	}
+}
+
+func TestDrainDeadline(t *testing.T) {
+	s := supervisor.New(zaptest.NewLogger(t), supervisor.Component{
+		Name: "stuck",
+		Run: func(ctx context.Context) error {
+			select {}
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := s.Run(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected to give up after the drain deadline, took %v", elapsed)
+	}
+}
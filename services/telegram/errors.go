@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	tgbotapi "github.com/dilfish/telegram-bot-api-up"
+)
+
+// errors returned by Messenger that handlers can react to
+var (
+	ErrBotKicked          = errors.New("bot is no longer a member of the chat")
+	ErrBotBlocked         = errors.New("bot was blocked by the user")
+	ErrChatNotFound       = errors.New("chat not found")
+	ErrMessageNotModified = errors.New("message is not modified")
+)
+
+// ChatMigratedError is returned when a group has been upgraded to a supergroup with a new chat id
+type ChatMigratedError struct {
+	ChatID          int64
+	MigrateToChatID int64
+}
+
+func (e *ChatMigratedError) Error() string {
+	return fmt.Sprintf("chat %d migrated to %d", e.ChatID, e.MigrateToChatID)
+}
+
+// classifyError maps telegram api errors to the typed errors above
+func classifyError(chatID int64, err error) error {
+	var apiErr tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	description := strings.ToLower(apiErr.Message)
+	switch {
+	case apiErr.MigrateToChatID != 0:
+		return &ChatMigratedError{ChatID: chatID, MigrateToChatID: apiErr.MigrateToChatID}
+	case strings.Contains(description, "message is not modified"):
+		return ErrMessageNotModified
+	case strings.Contains(description, "chat not found"):
+		return ErrChatNotFound
+	case apiErr.Code == http.StatusForbidden && strings.Contains(description, "blocked"):
+		return ErrBotBlocked
+	case apiErr.Code == http.StatusForbidden:
+		// kicked, not a member anymore, or the group was deleted
+		return ErrBotKicked
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// telegram limits bots to about 30 messages per second overall and 20 messages per minute in a group
+const (
+	globalRate      = rate.Limit(30)
+	globalBurst     = 30
+	chatRate        = rate.Limit(20.0 / 60)
+	chatBurst       = 5
+	chatLimiterIdle = 10 * time.Minute
+)
+
+// limiter is a global and per chat token bucket rate limiter
+type limiter struct {
+	global    *rate.Limiter
+	mu        sync.Mutex
+	chatRate  rate.Limit
+	chats     map[int64]*chatLimiter
+	lastSweep time.Time
+}
+
+type chatLimiter struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		global:    rate.NewLimiter(globalRate, globalBurst),
+		chatRate:  chatRate,
+		chats:     map[int64]*chatLimiter{},
+		lastSweep: time.Now(),
+	}
+}
+
+// wait blocks until both the chat's and the global bucket have a token, chatID 0 only waits on the global bucket
+func (l *limiter) wait(ctx context.Context, chatID int64) error {
+	if chatID != 0 {
+		if err := l.chat(chatID).Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return l.global.Wait(ctx)
+}
+
+func (l *limiter) chat(chatID int64) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > chatLimiterIdle {
+		// idle chats have full buckets again, so dropping them does not change behaviour
+		for id, c := range l.chats {
+			if now.Sub(c.lastUsed) > chatLimiterIdle {
+				delete(l.chats, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.chats[chatID]
+	if !ok {
+		c = &chatLimiter{limiter: rate.NewLimiter(l.chatRate, chatBurst)}
+		l.chats[chatID] = c
+	}
+	c.lastUsed = now
+	return c.limiter
+}
+
+func (l *limiter) setLimits(global rate.Limit, chat rate.Limit) {
+	l.global.SetLimit(global)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.chatRate = chat
+	for _, c := range l.chats {
+		c.limiter.SetLimit(chat)
+	}
+}
@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// waitNow reports whether the limiter has a token for the chat without waiting for one
+func waitNow(l *limiter, chatID int64) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	return l.wait(ctx, chatID) == nil
+}
+
+func TestLimiterChatBurst(t *testing.T) {
+	l := newLimiter()
+
+	for i := 0; i < chatBurst; i++ {
+		if !waitNow(l, 1) {
+			t.Fatalf("expected message %d of the burst to be sent immediately", i+1)
+		}
+	}
+	if waitNow(l, 1) {
+		t.Fatal("expected the chat to be limited after its burst")
+	}
+	if !waitNow(l, 2) {
+		t.Fatal("expected other chats not to be limited")
+	}
+	// requests that are not sent to a chat only wait on the global bucket
+	if !waitNow(l, 0) {
+		t.Fatal("expected requests without a chat not to be limited")
+	}
+}
+
+func TestLimiterGlobalBurst(t *testing.T) {
+	l := newLimiter()
+
+	for i := 0; i < globalBurst; i++ {
+		if !waitNow(l, 0) {
+			t.Fatalf("expected request %d of the burst to be sent immediately", i+1)
+		}
+	}
+	if waitNow(l, 2) {
+		t.Fatal("expected all chats to be limited after the global burst")
+	}
+}
+
+func TestLimiterSetLimits(t *testing.T) {
+	l := newLimiter()
+	l.setLimits(rate.Inf, rate.Inf)
+
+	for i := 0; i < globalBurst+chatBurst; i++ {
+		if !waitNow(l, 1) {
+			t.Fatalf("expected request %d not to be limited", i+1)
+		}
+	}
+}
+
+func TestLimiterForgetsIdleChats(t *testing.T) {
+	l := newLimiter()
+	l.chat(1)
+	l.chats[1].lastUsed = time.Now().Add(-2 * chatLimiterIdle)
+	l.lastSweep = time.Now().Add(-2 * chatLimiterIdle)
+
+	l.chat(2)
+	if _, ok := l.chats[1]; ok {
+		t.Fatal("expected the idle chat to be dropped")
+	}
+	if _, ok := l.chats[2]; !ok {
+		t.Fatal("expected the used chat to be kept")
+	}
+}
@@ -0,0 +1,23 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/dilfish/telegram-bot-api-up"
+)
+
+// Messenger sends and edits chat messages.
+// Errors are classified into ErrBotKicked, ErrBotBlocked, ErrChatNotFound, ErrMessageNotModified and *ChatMigratedError where possible.
+type Messenger interface {
+	// SendMessage returns the id of the sent message
+	SendMessage(ctx context.Context, chatID int64, formatHTML bool, text string) (int, error)
+	// SendInlineKeyboardMessage returns the id of the sent message
+	SendInlineKeyboardMessage(ctx context.Context, chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) (int, error)
+	EditMessage(ctx context.Context, chatID int64, messageID int, text string) error
+	AnswerCallbackQuery(ctx context.Context, callbackQueryID string, text string) error
+	IsChatAdmin(ctx context.Context, chatID int64, userID int64) (bool, error)
+	// Username of the bot, which commands can be addressed to as /command@username
+	Username() string
+}
+
+var _ Messenger = (*Bot)(nil)
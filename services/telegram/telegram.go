@@ -1,43 +1,92 @@
 package telegram
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
 	tgbotapi "github.com/dilfish/telegram-bot-api-up"
+	"github.com/gpng/order-bot/services/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// SecretTokenHeader is sent by telegram with every webhook request
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+const (
+	maxRetries   = 3
+	retryBackoff = time.Second
 )
 
+// tracer of bot api calls, a no-op unless tracing is set up
+var tracer = otel.Tracer("github.com/gpng/order-bot/services/telegram")
+
+// idempotentMethods can be retried after a server error, which telegram may return after the call took effect.
+// Editing a message again only fails with ErrMessageNotModified.
+var idempotentMethods = map[string]bool{
+	"getMe":               true,
+	"getChatMember":       true,
+	"getUpdates":          true,
+	"setMyCommands":       true,
+	"answerCallbackQuery": true,
+	"editMessageText":     true,
+}
+
+// allowedUpdates are the update types the bot handles
+var allowedUpdates = []string{"message", "callback_query"}
+
 // Bot with all methods
 type Bot struct {
-	BotAPI tgbotapi.BotAPI
+	BotAPI  tgbotapi.BotAPI
+	limiter *limiter
 }
 
 // New db connection and trigger migrations
 func New(token string) (*Bot, error) {
-	bot, err := tgbotapi.NewBotAPI(token)
+	return NewWithEndpoint(token, tgbotapi.APIEndpoint)
+}
+
+// NewWithEndpoint bot using a different bot api server, endpoint is formatted with the token and method
+func NewWithEndpoint(token string, endpoint string) (*Bot, error) {
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(token, endpoint)
 	if err != nil {
 		return nil, err
 	}
-	// connection string
-	return &Bot{*bot}, nil
+	return &Bot{*bot, newLimiter()}, nil
+}
+
+// SetRateLimits overrides the default global and per chat limits in events per second, rate.Inf disables limiting
+func (bot *Bot) SetRateLimits(global rate.Limit, chat rate.Limit) {
+	bot.limiter.setLimits(global, chat)
 }
 
 // SendMessage text
-func (bot *Bot) SendMessage(chatID int64, formatHTML bool, text string) {
+func (bot *Bot) SendMessage(ctx context.Context, chatID int64, formatHTML bool, text string) (int, error) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	if formatHTML {
 		msg.ParseMode = tgbotapi.ModeHTML
 	}
 	msg.DisableWebPagePreview = true
-	bot.BotAPI.Send(msg)
+	return bot.send(ctx, "sendMessage", chatID, msg)
 }
 
 // SendInlineKeyboardMessage with options
-func (bot *Bot) SendInlineKeyboardMessage(chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+func (bot *Bot) SendInlineKeyboardMessage(ctx context.Context, chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) (int, error) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ReplyMarkup = keyboard
-	bot.BotAPI.Send(msg)
+	return bot.send(ctx, "sendMessage", chatID, msg)
 }
 
 // EditMessage text
-func (bot *Bot) EditMessage(chatID int64, messageID int, text string) {
+func (bot *Bot) EditMessage(ctx context.Context, chatID int64, messageID int, text string) error {
 	msg := tgbotapi.EditMessageTextConfig{
 		BaseEdit: tgbotapi.BaseEdit{
 			ChatID:    chatID,
@@ -45,5 +94,173 @@ func (bot *Bot) EditMessage(chatID int64, messageID int, text string) {
 		},
 		Text: text,
 	}
-	bot.BotAPI.Send(msg)
+	_, err := bot.send(ctx, "editMessageText", chatID, msg)
+	return err
+}
+
+// AnswerCallbackQuery to stop the loading indicator on inline keyboard buttons
+func (bot *Bot) AnswerCallbackQuery(ctx context.Context, callbackQueryID string, text string) error {
+	return bot.do(ctx, "answerCallbackQuery", 0, func() error {
+		_, err := bot.BotAPI.AnswerCallbackQuery(tgbotapi.NewCallback(callbackQueryID, text))
+		return err
+	})
+}
+
+// IsChatAdmin checks if user is the creator or an administrator of the chat
+func (bot *Bot) IsChatAdmin(ctx context.Context, chatID int64, userID int64) (bool, error) {
+	var member tgbotapi.ChatMember
+	err := bot.do(ctx, "getChatMember", 0, func() error {
+		var err error
+		member, err = bot.BotAPI.GetChatMember(tgbotapi.ChatConfigWithUser{
+			ChatID: chatID,
+			UserID: int(userID),
+		})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return member.IsCreator() || member.IsAdministrator(), nil
+}
+
+// Ping checks that telegram is reachable and the token is valid with getMe
+func (bot *Bot) Ping(ctx context.Context) error {
+	return bot.do(ctx, "getMe", 0, func() error {
+		_, err := bot.BotAPI.GetMe()
+		return err
+	})
+}
+
+// Username of the bot, fetched with getMe when the bot was created
+func (bot *Bot) Username() string {
+	return bot.BotAPI.Self.UserName
+}
+
+// SetCommands shown by telegram clients of users with languageCode, or of all other users when it is empty
+func (bot *Bot) SetCommands(ctx context.Context, commands []tgbotapi.BotCommand, languageCode string) error {
+	data, err := json.Marshal(commands)
+	if err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Add("commands", string(data))
+	if languageCode != "" {
+		v.Add("language_code", languageCode)
+	}
+	return bot.do(ctx, "setMyCommands", 0, func() error {
+		_, err := bot.BotAPI.MakeRequest("setMyCommands", v)
+		return err
+	})
+}
+
+// SetWebhook registers the webhook url, telegram sends secret in the SecretTokenHeader of every update
+func (bot *Bot) SetWebhook(webhookURL string, secret string) error {
+	updates, err := json.Marshal(allowedUpdates)
+	if err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Add("url", webhookURL)
+	v.Add("secret_token", secret)
+	v.Add("allowed_updates", string(updates))
+	_, err = bot.BotAPI.MakeRequest("setWebhook", v)
+	return err
+}
+
+// RemoveWebhook so that updates can be received with GetUpdates
+func (bot *Bot) RemoveWebhook() error {
+	_, err := bot.BotAPI.RemoveWebhook()
+	return err
+}
+
+// GetUpdates long polls for updates after offset, returning the raw update array
+func (bot *Bot) GetUpdates(offset int, timeout int) (json.RawMessage, error) {
+	updates, err := json.Marshal(allowedUpdates)
+	if err != nil {
+		return nil, err
+	}
+	v := url.Values{}
+	v.Add("offset", strconv.Itoa(offset))
+	v.Add("timeout", strconv.Itoa(timeout))
+	v.Add("allowed_updates", string(updates))
+	resp, err := bot.BotAPI.MakeRequest("getUpdates", v)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+func (bot *Bot) send(ctx context.Context, method string, chatID int64, c tgbotapi.Chattable) (int, error) {
+	var msg tgbotapi.Message
+	err := bot.do(ctx, method, chatID, func() error {
+		var err error
+		msg, err = bot.BotAPI.Send(c)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return msg.MessageID, nil
+}
+
+// do rate limits fn and retries it on 429, honouring retry_after, and on server errors of idempotent methods.
+// method is the bot api method called by fn, for metrics.
+func (bot *Bot) do(ctx context.Context, method string, chatID int64, fn func() error) (err error) {
+	ctx, span := tracer.Start(ctx, "telegram "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("telegram.method", method),
+		attribute.Int64("telegram.chat_id", chatID),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	for attempt := 0; ; attempt++ {
+		if err := bot.limiter.wait(ctx, chatID); err != nil {
+			metrics.TelegramErrors.WithLabelValues(method).Inc()
+			return err
+		}
+
+		start := time.Now()
+		err := fn()
+		metrics.TelegramDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if err == nil {
+			return nil
+		}
+
+		delay, ok := retryDelay(method, err, attempt)
+		if !ok || attempt >= maxRetries {
+			metrics.TelegramErrors.WithLabelValues(method).Inc()
+			return classifyError(chatID, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			metrics.TelegramErrors.WithLabelValues(method).Inc()
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay returns how long to wait before retrying err of method, and false if it should not be retried.
+// Other methods are not retried after a server error, as a message may have been sent despite it.
+func retryDelay(method string, err error, attempt int) (time.Duration, bool) {
+	var apiErr tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	if apiErr.Code == http.StatusTooManyRequests {
+		if apiErr.RetryAfter > 0 {
+			return time.Duration(apiErr.RetryAfter) * time.Second, true
+		}
+		return retryBackoff << attempt, true
+	}
+	if apiErr.Code >= http.StatusInternalServerError && idempotentMethods[method] {
+		return retryBackoff << attempt, true
+	}
+	return 0, false
 }
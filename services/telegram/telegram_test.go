@@ -0,0 +1,165 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/dilfish/telegram-bot-api-up"
+	"github.com/gpng/order-bot/services/telegram/telegramtest"
+)
+
+func newTestBot(t *testing.T) (*Bot, *telegramtest.Server) {
+	t.Helper()
+	server := telegramtest.NewServer()
+	t.Cleanup(server.Close)
+
+	bot, err := NewWithEndpoint(telegramtest.BotToken, server.Endpoint())
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+	return bot, server
+}
+
+func TestRetryAfter(t *testing.T) {
+	bot, server := newTestBot(t)
+
+	server.Fail("sendMessage", telegramtest.Failure{
+		Code:        http.StatusTooManyRequests,
+		Description: "Too Many Requests: retry after 1",
+		Parameters:  tgbotapi.ResponseParameters{RetryAfter: 1},
+	})
+	start := time.Now()
+	if _, err := bot.SendMessage(context.Background(), 1, false, "kopi"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected the retry to wait retry_after, retried after %v", elapsed)
+	}
+	if calls := server.Calls("sendMessage"); calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+	if sent := server.Sent(1); len(sent) != 1 || sent[0].Text != "kopi" {
+		t.Fatalf("expected the message to be sent once, got %+v", sent)
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	bot, server := newTestBot(t)
+
+	server.Fail("sendMessage", telegramtest.Failure{
+		Code:        http.StatusTooManyRequests,
+		Description: "Too Many Requests: retry after 60",
+		Parameters:  tgbotapi.ResponseParameters{RetryAfter: 60},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := bot.SendMessage(ctx, 1, false, "kopi"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SendMessage err = %v, want DeadlineExceeded", err)
+	}
+	if calls := server.Calls("sendMessage"); calls != 1 {
+		t.Fatalf("expected no retry after ctx is done, got %d calls", calls)
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	bot, server := newTestBot(t)
+
+	server.Fail("sendMessage", telegramtest.Failure{Code: http.StatusBadRequest, Description: "Bad Request: message is too long"})
+	_, err := bot.SendMessage(context.Background(), 1, false, "kopi")
+	var apiErr tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+		t.Fatalf("SendMessage err = %v, want the bad request", err)
+	}
+	if calls := server.Calls("sendMessage"); calls != 1 {
+		t.Fatalf("expected no retry, got %d calls", calls)
+	}
+}
+
+func TestServerErrorOfSendIsNotRetried(t *testing.T) {
+	bot, server := newTestBot(t)
+
+	// telegram may have sent the message before failing, so sending again could post it twice
+	server.Fail("sendMessage", telegramtest.Failure{Code: http.StatusBadGateway, Description: "Bad Gateway"})
+	if _, err := bot.SendMessage(context.Background(), 1, false, "kopi"); err == nil {
+		t.Fatal("expected the server error to be returned")
+	}
+	if calls := server.Calls("sendMessage"); calls != 1 {
+		t.Fatalf("expected no retry, got %d calls", calls)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		err     error
+		attempt int
+		want    time.Duration
+		wantOK  bool
+	}{
+		{"retry after", "sendMessage", tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5}}, 0, 5 * time.Second, true},
+		{"too many requests without retry after", "sendMessage", tgbotapi.Error{Code: 429}, 2, 4 * retryBackoff, true},
+		{"server error of idempotent method", "getChatMember", tgbotapi.Error{Code: 502}, 1, 2 * retryBackoff, true},
+		{"server error of send", "sendMessage", tgbotapi.Error{Code: 502}, 0, 0, false},
+		{"bad request", "getChatMember", tgbotapi.Error{Code: 400}, 0, 0, false},
+		{"network error", "getChatMember", errors.New("connection reset"), 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryDelay(tt.method, tt.err, tt.attempt)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("retryDelay = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		failure telegramtest.Failure
+		want    error
+	}{
+		{"kicked", "sendMessage", telegramtest.Failure{Code: 403, Description: "Forbidden: bot was kicked from the group chat"}, ErrBotKicked},
+		{"not a member", "sendMessage", telegramtest.Failure{Code: 403, Description: "Forbidden: bot is not a member of the supergroup chat"}, ErrBotKicked},
+		{"blocked", "sendMessage", telegramtest.Failure{Code: 403, Description: "Forbidden: bot was blocked by the user"}, ErrBotBlocked},
+		{"chat not found", "sendMessage", telegramtest.Failure{Code: 400, Description: "Bad Request: chat not found"}, ErrChatNotFound},
+		{"not modified", "editMessageText", telegramtest.Failure{Code: 400, Description: "Bad Request: message is not modified"}, ErrMessageNotModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, server := newTestBot(t)
+
+			server.Fail(tt.method, tt.failure)
+			var err error
+			if tt.method == "editMessageText" {
+				err = bot.EditMessage(context.Background(), 1, 1, "kopi")
+			} else {
+				_, err = bot.SendMessage(context.Background(), 1, false, "kopi")
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatMigratedError(t *testing.T) {
+	bot, server := newTestBot(t)
+
+	server.Fail("sendMessage", telegramtest.Failure{
+		Code:        400,
+		Description: "Bad Request: group chat was upgraded to a supergroup chat",
+		Parameters:  tgbotapi.ResponseParameters{MigrateToChatID: -1002},
+	})
+	_, err := bot.SendMessage(context.Background(), -1001, false, "kopi")
+	var migrated *ChatMigratedError
+	if !errors.As(err, &migrated) || migrated.ChatID != -1001 || migrated.MigrateToChatID != -1002 {
+		t.Fatalf("err = %v, want the chat migrated to -1002", err)
+	}
+}
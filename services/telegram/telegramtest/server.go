@@ -0,0 +1,323 @@
+package telegramtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/dilfish/telegram-bot-api-up"
+)
+
+// bot returned by getMe
+const (
+	BotID       = 123456789
+	BotUsername = "HelpMeBuyLehBot"
+	BotToken    = "123456789:TEST"
+)
+
+// Message sent or edited through the fake server
+type Message struct {
+	ChatID      int64
+	MessageID   int
+	Text        string
+	ParseMode   string
+	ReplyMarkup *tgbotapi.InlineKeyboardMarkup
+}
+
+// Button returns the callback data of the inline keyboard button with text, and false if there is none
+func (m Message) Button(text string) (string, bool) {
+	if m.ReplyMarkup == nil {
+		return "", false
+	}
+	for _, row := range m.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if button.Text == text && button.CallbackData != nil {
+				return *button.CallbackData, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Failure returned instead of the result of a method, see Fail
+type Failure struct {
+	Code        int
+	Description string
+	Parameters  tgbotapi.ResponseParameters
+}
+
+// Server is an in-process fake of the telegram bot api that records sent and edited messages
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	nextMessageID int
+	sent          []Message
+	edited        []Message
+	answered      []string
+	admins        map[int64]map[int64]bool
+	kicked        map[int64]bool
+	commands      map[string][]tgbotapi.BotCommand
+	failures      map[string][]Failure
+	calls         map[string]int
+}
+
+// NewServer starts a fake bot api server, callers should Close it when done
+func NewServer() *Server {
+	s := &Server{
+		nextMessageID: 1,
+		admins:        map[int64]map[int64]bool{},
+		kicked:        map[int64]bool{},
+		commands:      map[string][]tgbotapi.BotCommand{},
+		failures:      map[string][]Failure{},
+		calls:         map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Endpoint to pass to telegram.NewWithEndpoint
+func (s *Server) Endpoint() string {
+	return s.URL + "/bot%s/%s"
+}
+
+// SetAdmin makes user an administrator of the chat for getChatMember
+func (s *Server) SetAdmin(chatID int64, userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.admins[chatID] == nil {
+		s.admins[chatID] = map[int64]bool{}
+	}
+	s.admins[chatID][userID] = true
+}
+
+// Kick makes requests to the chat fail as if the bot was removed from it
+func (s *Server) Kick(chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kicked[chatID] = true
+}
+
+// Fail makes the next calls of method return failures, one call per failure in order
+func (s *Server) Fail(method string, failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], failures...)
+}
+
+// Calls of method, including failed ones
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// Sent returns the messages sent to the chat, oldest first
+func (s *Server) Sent(chatID int64) []Message {
+	return s.filter(func() []Message { return s.sent }, chatID)
+}
+
+// Edited returns the message edits in the chat, oldest first
+func (s *Server) Edited(chatID int64) []Message {
+	return s.filter(func() []Message { return s.edited }, chatID)
+}
+
+// LastSent returns the latest message sent to the chat, and false if there is none
+func (s *Server) LastSent(chatID int64) (Message, bool) {
+	sent := s.Sent(chatID)
+	if len(sent) == 0 {
+		return Message{}, false
+	}
+	return sent[len(sent)-1], true
+}
+
+// AnsweredCallbackQueries returns the ids of answered callback queries
+func (s *Server) AnsweredCallbackQueries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.answered...)
+}
+
+// Commands set with setMyCommands for languageCode, empty for the default commands
+func (s *Server) Commands(languageCode string) []tgbotapi.BotCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands[languageCode]
+}
+
+// Reset forgets all recorded messages
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = nil
+	s.edited = nil
+	s.answered = nil
+}
+
+func (s *Server) filter(messages func() []Message, chatID int64) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filtered := []Message{}
+	for _, m := range messages() {
+		if m.ChatID == chatID {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// paths are /bot<token>/<method>
+	split := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(split) != 2 || split[0] != "bot"+BotToken {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[split[1]]++
+	failures := s.failures[split[1]]
+	if len(failures) > 0 {
+		s.failures[split[1]] = failures[1:]
+	}
+	s.mu.Unlock()
+	if len(failures) > 0 {
+		respondFailure(w, failures[0])
+		return
+	}
+
+	switch split[1] {
+	case "getMe":
+		respond(w, tgbotapi.User{ID: BotID, IsBot: true, FirstName: BotUsername, UserName: BotUsername})
+	case "sendMessage":
+		s.handleSendMessage(w, r)
+	case "editMessageText":
+		s.handleEditMessageText(w, r)
+	case "answerCallbackQuery":
+		s.mu.Lock()
+		s.answered = append(s.answered, r.FormValue("callback_query_id"))
+		s.mu.Unlock()
+		respond(w, true)
+	case "getChatMember":
+		s.handleGetChatMember(w, r)
+	case "setMyCommands":
+		var commands []tgbotapi.BotCommand
+		if err := json.Unmarshal([]byte(r.FormValue("commands")), &commands); err != nil {
+			respondError(w, http.StatusBadRequest, "Bad Request: can't parse commands")
+			return
+		}
+		s.mu.Lock()
+		s.commands[r.FormValue("language_code")] = commands
+		s.mu.Unlock()
+		respond(w, true)
+	case "setWebhook", "deleteWebhook":
+		respond(w, true)
+	case "getUpdates":
+		respond(w, []interface{}{})
+	default:
+		respondError(w, http.StatusNotFound, "Not Found: method not found")
+	}
+}
+
+func (s *Server) handleSendMessage(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.parseMessage(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	m.MessageID = s.nextMessageID
+	s.nextMessageID++
+	s.sent = append(s.sent, m)
+	s.mu.Unlock()
+
+	respond(w, tgbotapi.Message{MessageID: m.MessageID, Chat: &tgbotapi.Chat{ID: m.ChatID}, Text: m.Text})
+}
+
+func (s *Server) handleEditMessageText(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.parseMessage(w, r)
+	if !ok {
+		return
+	}
+	m.MessageID, _ = strconv.Atoi(r.FormValue("message_id"))
+
+	s.mu.Lock()
+	s.edited = append(s.edited, m)
+	s.mu.Unlock()
+
+	respond(w, tgbotapi.Message{MessageID: m.MessageID, Chat: &tgbotapi.Chat{ID: m.ChatID}, Text: m.Text})
+}
+
+func (s *Server) parseMessage(w http.ResponseWriter, r *http.Request) (Message, bool) {
+	chatID, err := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Bad Request: chat not found")
+		return Message{}, false
+	}
+
+	s.mu.Lock()
+	kicked := s.kicked[chatID]
+	s.mu.Unlock()
+	if kicked {
+		respondError(w, http.StatusForbidden, "Forbidden: bot was kicked from the group chat")
+		return Message{}, false
+	}
+
+	m := Message{
+		ChatID:    chatID,
+		Text:      r.FormValue("text"),
+		ParseMode: r.FormValue("parse_mode"),
+	}
+	if markup := r.FormValue("reply_markup"); markup != "" {
+		m.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{}
+		if err := json.Unmarshal([]byte(markup), m.ReplyMarkup); err != nil {
+			respondError(w, http.StatusBadRequest, "Bad Request: can't parse reply keyboard markup JSON object")
+			return Message{}, false
+		}
+	}
+	return m, true
+}
+
+func (s *Server) handleGetChatMember(w http.ResponseWriter, r *http.Request) {
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	userID, _ := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+
+	s.mu.Lock()
+	isAdmin := s.admins[chatID][userID]
+	s.mu.Unlock()
+
+	status := "member"
+	if isAdmin {
+		status = "administrator"
+	}
+	respond(w, tgbotapi.ChatMember{User: &tgbotapi.User{ID: int(userID)}, Status: status})
+}
+
+func respond(w http.ResponseWriter, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: data})
+}
+
+func respondFailure(w http.ResponseWriter, f Failure) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.Code)
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: false, ErrorCode: f.Code, Description: f.Description, Parameters: &f.Parameters})
+}
+
+func respondError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: false, ErrorCode: code, Description: description})
+}
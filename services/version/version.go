@@ -0,0 +1,8 @@
+package version
+
+// Build information, injected at build time with
+// -ldflags "-X github.com/gpng/order-bot/services/version.Version=v1.2.3 -X github.com/gpng/order-bot/services/version.Commit=abc1234"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
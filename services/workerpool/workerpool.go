@@ -0,0 +1,108 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ErrClosed is returned when submitting to a pool that is shutting down
+var ErrClosed = errors.New("worker pool closed")
+
+// Pool runs tasks on a fixed number of workers with bounded queues.
+// Tasks submitted with the same key always run on the same worker, in submission order.
+type Pool struct {
+	logger   *zap.Logger
+	queues   []chan func()
+	done     chan struct{}
+	doneOnce sync.Once
+	mu       sync.RWMutex
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// New pool with workers goroutines, each buffering up to queueSize tasks
+func New(workers int, queueSize int, logger *zap.Logger) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{
+		logger: logger,
+		queues: make([]chan func(), workers),
+		done:   make(chan struct{}),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan func(), queueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+// Submit queues fn on the key's worker, blocking while its queue is full until ctx is done
+func (p *Pool) Submit(ctx context.Context, key int64, fn func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.queues[uint64(key)%uint64(len(p.queues))] <- fn:
+		return nil
+	case <-p.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops accepting tasks and waits for queued tasks to finish until ctx is done
+func (p *Pool) Shutdown(ctx context.Context) error {
+	// unblock submitters waiting on full queues before taking the lock
+	p.doneOnce.Do(func() { close(p.done) })
+	p.close()
+
+	finished := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	for _, queue := range p.queues {
+		close(queue)
+	}
+}
+
+func (p *Pool) work(queue chan func()) {
+	defer p.wg.Done()
+	for fn := range queue {
+		p.run(fn)
+	}
+}
+
+func (p *Pool) run(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("worker pool task panicked", zap.Any("panic", r), zap.Stack("stack"))
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,162 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/services/workerpool"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSameKeyRunsInOrder(t *testing.T) {
+	p := workerpool.New(4, 100, zaptest.NewLogger(t))
+
+	var (
+		mu  sync.Mutex
+		ran []int
+	)
+	want := make([]int, 100)
+	for i := range want {
+		i := i
+		want[i] = i
+		if err := p.Submit(context.Background(), 1, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, i)
+		}); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if !reflect.DeepEqual(ran, want) {
+		t.Fatalf("tasks ran in order %v, want %v", ran, want)
+	}
+}
+
+func TestDifferentKeysRunConcurrently(t *testing.T) {
+	p := workerpool.New(2, 1, zaptest.NewLogger(t))
+	defer p.Shutdown(context.Background())
+
+	// the first task only finishes once the second has run, so they deadlock if they share a worker
+	second := make(chan struct{})
+	first := make(chan bool, 1)
+	p.Submit(context.Background(), 0, func() {
+		select {
+		case <-second:
+			first <- true
+		case <-time.After(5 * time.Second):
+			first <- false
+		}
+	})
+	p.Submit(context.Background(), 1, func() {
+		close(second)
+	})
+
+	if !<-first {
+		t.Fatal("expected tasks of different keys to run concurrently")
+	}
+}
+
+func TestShutdownDrainsQueuedTasks(t *testing.T) {
+	p := workerpool.New(1, 10, zaptest.NewLogger(t))
+
+	release := make(chan struct{})
+	var (
+		mu  sync.Mutex
+		ran int
+	)
+	task := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		ran++
+	}
+	p.Submit(context.Background(), 1, func() {
+		<-release
+		task()
+	})
+	for i := 0; i < 5; i++ {
+		p.Submit(context.Background(), 1, task)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- p.Shutdown(context.Background())
+	}()
+	close(release)
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if ran != 6 {
+		t.Fatalf("expected queued tasks to run before Shutdown returns, %d of 6 ran", ran)
+	}
+	if err := p.Submit(context.Background(), 1, task); !errors.Is(err, workerpool.ErrClosed) {
+		t.Fatalf("Submit after Shutdown err = %v, want ErrClosed", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	p := workerpool.New(1, 1, zaptest.NewLogger(t))
+
+	release := make(chan struct{})
+	defer close(release)
+	p.Submit(context.Background(), 1, func() {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestSubmitWaitsForFullQueue(t *testing.T) {
+	p := workerpool.New(1, 1, zaptest.NewLogger(t))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit(context.Background(), 1, func() {
+		close(started)
+		<-release
+	})
+	<-started
+	p.Submit(context.Background(), 1, func() {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, 1, func() {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Submit to a full queue err = %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestPanicDoesNotStopWorker(t *testing.T) {
+	p := workerpool.New(1, 2, zaptest.NewLogger(t))
+
+	ran := false
+	p.Submit(context.Background(), 1, func() {
+		panic("task failed")
+	})
+	p.Submit(context.Background(), 1, func() {
+		ran = true
+	})
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if !ran {
+		t.Fatal("expected the task after a panic to run")
+	}
+}
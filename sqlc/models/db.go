@@ -22,15 +22,30 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addItemQuantityStmt, err = db.PrepareContext(ctx, addItemQuantity); err != nil {
+		return nil, fmt.Errorf("error preparing query AddItemQuantity: %w", err)
+	}
 	if q.cancelOrderStmt, err = db.PrepareContext(ctx, cancelOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query CancelOrder: %w", err)
 	}
+	if q.closeOrderStmt, err = db.PrepareContext(ctx, closeOrder); err != nil {
+		return nil, fmt.Errorf("error preparing query CloseOrder: %w", err)
+	}
+	if q.countActiveOrdersStmt, err = db.PrepareContext(ctx, countActiveOrders); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveOrders: %w", err)
+	}
+	if q.createAPIKeyStmt, err = db.PrepareContext(ctx, createAPIKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAPIKey: %w", err)
+	}
 	if q.createItemStmt, err = db.PrepareContext(ctx, createItem); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateItem: %w", err)
 	}
 	if q.createOrderStmt, err = db.PrepareContext(ctx, createOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateOrder: %w", err)
 	}
+	if q.createOrderEventStmt, err = db.PrepareContext(ctx, createOrderEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOrderEvent: %w", err)
+	}
 	if q.deactivateOrderStmt, err = db.PrepareContext(ctx, deactivateOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query DeactivateOrder: %w", err)
 	}
@@ -40,18 +55,63 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getActiveOrderStmt, err = db.PrepareContext(ctx, getActiveOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query GetActiveOrder: %w", err)
 	}
+	if q.getActiveOrderForUpdateStmt, err = db.PrepareContext(ctx, getActiveOrderForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetActiveOrderForUpdate: %w", err)
+	}
+	if q.getActiveOrdersWithExpiryStmt, err = db.PrepareContext(ctx, getActiveOrdersWithExpiry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetActiveOrdersWithExpiry: %w", err)
+	}
+	if q.getAPIKeyByHashStmt, err = db.PrepareContext(ctx, getAPIKeyByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAPIKeyByHash: %w", err)
+	}
+	if q.getAPIKeysByChatIDStmt, err = db.PrepareContext(ctx, getAPIKeysByChatID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAPIKeysByChatID: %w", err)
+	}
+	if q.getChatStmt, err = db.PrepareContext(ctx, getChat); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChat: %w", err)
+	}
 	if q.getItemStmt, err = db.PrepareContext(ctx, getItem); err != nil {
 		return nil, fmt.Errorf("error preparing query GetItem: %w", err)
 	}
 	if q.getItemsByOrderIDStmt, err = db.PrepareContext(ctx, getItemsByOrderID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetItemsByOrderID: %w", err)
 	}
+	if q.getItemsWithUsersByOrderIDStmt, err = db.PrepareContext(ctx, getItemsWithUsersByOrderID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetItemsWithUsersByOrderID: %w", err)
+	}
+	if q.getLargeChatIDsStmt, err = db.PrepareContext(ctx, getLargeChatIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLargeChatIDs: %w", err)
+	}
+	if q.getLargeUserIDsStmt, err = db.PrepareContext(ctx, getLargeUserIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLargeUserIDs: %w", err)
+	}
 	if q.getOrderByIDStmt, err = db.PrepareContext(ctx, getOrderByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOrderByID: %w", err)
 	}
+	if q.getOrdersByChatIDStmt, err = db.PrepareContext(ctx, getOrdersByChatID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrdersByChatID: %w", err)
+	}
+	if q.getRecentOrderEventsStmt, err = db.PrepareContext(ctx, getRecentOrderEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRecentOrderEvents: %w", err)
+	}
+	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
+	}
 	if q.getUserItemsStmt, err = db.PrepareContext(ctx, getUserItems); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserItems: %w", err)
 	}
+	if q.migrateChatIDStmt, err = db.PrepareContext(ctx, migrateChatID); err != nil {
+		return nil, fmt.Errorf("error preparing query MigrateChatID: %w", err)
+	}
+	if q.relinkWrappedUserIDStmt, err = db.PrepareContext(ctx, relinkWrappedUserID); err != nil {
+		return nil, fmt.Errorf("error preparing query RelinkWrappedUserID: %w", err)
+	}
+	if q.revokeAPIKeyStmt, err = db.PrepareContext(ctx, revokeAPIKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeAPIKey: %w", err)
+	}
+	if q.setChatLanguageStmt, err = db.PrepareContext(ctx, setChatLanguage); err != nil {
+		return nil, fmt.Errorf("error preparing query SetChatLanguage: %w", err)
+	}
 	if q.updateExpiryStmt, err = db.PrepareContext(ctx, updateExpiry); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateExpiry: %w", err)
 	}
@@ -61,16 +121,42 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.updateReminderStmt, err = db.PrepareContext(ctx, updateReminder); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateReminder: %w", err)
 	}
+	if q.upsertChatStmt, err = db.PrepareContext(ctx, upsertChat); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertChat: %w", err)
+	}
+	if q.upsertUserStmt, err = db.PrepareContext(ctx, upsertUser); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertUser: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addItemQuantityStmt != nil {
+		if cerr := q.addItemQuantityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addItemQuantityStmt: %w", cerr)
+		}
+	}
 	if q.cancelOrderStmt != nil {
 		if cerr := q.cancelOrderStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing cancelOrderStmt: %w", cerr)
 		}
 	}
+	if q.closeOrderStmt != nil {
+		if cerr := q.closeOrderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing closeOrderStmt: %w", cerr)
+		}
+	}
+	if q.countActiveOrdersStmt != nil {
+		if cerr := q.countActiveOrdersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveOrdersStmt: %w", cerr)
+		}
+	}
+	if q.createAPIKeyStmt != nil {
+		if cerr := q.createAPIKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAPIKeyStmt: %w", cerr)
+		}
+	}
 	if q.createItemStmt != nil {
 		if cerr := q.createItemStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createItemStmt: %w", cerr)
@@ -81,6 +167,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createOrderStmt: %w", cerr)
 		}
 	}
+	if q.createOrderEventStmt != nil {
+		if cerr := q.createOrderEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOrderEventStmt: %w", cerr)
+		}
+	}
 	if q.deactivateOrderStmt != nil {
 		if cerr := q.deactivateOrderStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deactivateOrderStmt: %w", cerr)
@@ -96,6 +187,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getActiveOrderStmt: %w", cerr)
 		}
 	}
+	if q.getActiveOrderForUpdateStmt != nil {
+		if cerr := q.getActiveOrderForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getActiveOrderForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getActiveOrdersWithExpiryStmt != nil {
+		if cerr := q.getActiveOrdersWithExpiryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getActiveOrdersWithExpiryStmt: %w", cerr)
+		}
+	}
+	if q.getAPIKeyByHashStmt != nil {
+		if cerr := q.getAPIKeyByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAPIKeyByHashStmt: %w", cerr)
+		}
+	}
+	if q.getAPIKeysByChatIDStmt != nil {
+		if cerr := q.getAPIKeysByChatIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAPIKeysByChatIDStmt: %w", cerr)
+		}
+	}
+	if q.getChatStmt != nil {
+		if cerr := q.getChatStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChatStmt: %w", cerr)
+		}
+	}
 	if q.getItemStmt != nil {
 		if cerr := q.getItemStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getItemStmt: %w", cerr)
@@ -106,16 +222,66 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getItemsByOrderIDStmt: %w", cerr)
 		}
 	}
+	if q.getItemsWithUsersByOrderIDStmt != nil {
+		if cerr := q.getItemsWithUsersByOrderIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getItemsWithUsersByOrderIDStmt: %w", cerr)
+		}
+	}
+	if q.getLargeChatIDsStmt != nil {
+		if cerr := q.getLargeChatIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLargeChatIDsStmt: %w", cerr)
+		}
+	}
+	if q.getLargeUserIDsStmt != nil {
+		if cerr := q.getLargeUserIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLargeUserIDsStmt: %w", cerr)
+		}
+	}
 	if q.getOrderByIDStmt != nil {
 		if cerr := q.getOrderByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getOrderByIDStmt: %w", cerr)
 		}
 	}
+	if q.getOrdersByChatIDStmt != nil {
+		if cerr := q.getOrdersByChatIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrdersByChatIDStmt: %w", cerr)
+		}
+	}
+	if q.getRecentOrderEventsStmt != nil {
+		if cerr := q.getRecentOrderEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRecentOrderEventsStmt: %w", cerr)
+		}
+	}
+	if q.getUserStmt != nil {
+		if cerr := q.getUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
+		}
+	}
 	if q.getUserItemsStmt != nil {
 		if cerr := q.getUserItemsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserItemsStmt: %w", cerr)
 		}
 	}
+	if q.migrateChatIDStmt != nil {
+		if cerr := q.migrateChatIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing migrateChatIDStmt: %w", cerr)
+		}
+	}
+	if q.relinkWrappedUserIDStmt != nil {
+		if cerr := q.relinkWrappedUserIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing relinkWrappedUserIDStmt: %w", cerr)
+		}
+	}
+	if q.revokeAPIKeyStmt != nil {
+		if cerr := q.revokeAPIKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeAPIKeyStmt: %w", cerr)
+		}
+	}
+	if q.setChatLanguageStmt != nil {
+		if cerr := q.setChatLanguageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setChatLanguageStmt: %w", cerr)
+		}
+	}
 	if q.updateExpiryStmt != nil {
 		if cerr := q.updateExpiryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateExpiryStmt: %w", cerr)
@@ -131,6 +297,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateReminderStmt: %w", cerr)
 		}
 	}
+	if q.upsertChatStmt != nil {
+		if cerr := q.upsertChatStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertChatStmt: %w", cerr)
+		}
+	}
+	if q.upsertUserStmt != nil {
+		if cerr := q.upsertUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertUserStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -168,39 +344,83 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                     DBTX
-	tx                     *sql.Tx
-	cancelOrderStmt        *sql.Stmt
-	createItemStmt         *sql.Stmt
-	createOrderStmt        *sql.Stmt
-	deactivateOrderStmt    *sql.Stmt
-	deleteItemByUserStmt   *sql.Stmt
-	getActiveOrderStmt     *sql.Stmt
-	getItemStmt            *sql.Stmt
-	getItemsByOrderIDStmt  *sql.Stmt
-	getOrderByIDStmt       *sql.Stmt
-	getUserItemsStmt       *sql.Stmt
-	updateExpiryStmt       *sql.Stmt
-	updateItemQuantityStmt *sql.Stmt
-	updateReminderStmt     *sql.Stmt
+	db                             DBTX
+	tx                             *sql.Tx
+	addItemQuantityStmt            *sql.Stmt
+	cancelOrderStmt                *sql.Stmt
+	closeOrderStmt                 *sql.Stmt
+	countActiveOrdersStmt          *sql.Stmt
+	createAPIKeyStmt               *sql.Stmt
+	createItemStmt                 *sql.Stmt
+	createOrderStmt                *sql.Stmt
+	createOrderEventStmt           *sql.Stmt
+	deactivateOrderStmt            *sql.Stmt
+	deleteItemByUserStmt           *sql.Stmt
+	getActiveOrderStmt             *sql.Stmt
+	getActiveOrderForUpdateStmt    *sql.Stmt
+	getActiveOrdersWithExpiryStmt  *sql.Stmt
+	getAPIKeyByHashStmt            *sql.Stmt
+	getAPIKeysByChatIDStmt         *sql.Stmt
+	getChatStmt                    *sql.Stmt
+	getItemStmt                    *sql.Stmt
+	getItemsByOrderIDStmt          *sql.Stmt
+	getItemsWithUsersByOrderIDStmt *sql.Stmt
+	getLargeChatIDsStmt            *sql.Stmt
+	getLargeUserIDsStmt            *sql.Stmt
+	getOrderByIDStmt               *sql.Stmt
+	getOrdersByChatIDStmt          *sql.Stmt
+	getRecentOrderEventsStmt       *sql.Stmt
+	getUserStmt                    *sql.Stmt
+	getUserItemsStmt               *sql.Stmt
+	migrateChatIDStmt              *sql.Stmt
+	relinkWrappedUserIDStmt        *sql.Stmt
+	revokeAPIKeyStmt               *sql.Stmt
+	setChatLanguageStmt            *sql.Stmt
+	updateExpiryStmt               *sql.Stmt
+	updateItemQuantityStmt         *sql.Stmt
+	updateReminderStmt             *sql.Stmt
+	upsertChatStmt                 *sql.Stmt
+	upsertUserStmt                 *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                     tx,
-		tx:                     tx,
-		cancelOrderStmt:        q.cancelOrderStmt,
-		createItemStmt:         q.createItemStmt,
-		createOrderStmt:        q.createOrderStmt,
-		deactivateOrderStmt:    q.deactivateOrderStmt,
-		deleteItemByUserStmt:   q.deleteItemByUserStmt,
-		getActiveOrderStmt:     q.getActiveOrderStmt,
-		getItemStmt:            q.getItemStmt,
-		getItemsByOrderIDStmt:  q.getItemsByOrderIDStmt,
-		getOrderByIDStmt:       q.getOrderByIDStmt,
-		getUserItemsStmt:       q.getUserItemsStmt,
-		updateExpiryStmt:       q.updateExpiryStmt,
-		updateItemQuantityStmt: q.updateItemQuantityStmt,
-		updateReminderStmt:     q.updateReminderStmt,
+		db:                             tx,
+		tx:                             tx,
+		addItemQuantityStmt:            q.addItemQuantityStmt,
+		cancelOrderStmt:                q.cancelOrderStmt,
+		closeOrderStmt:                 q.closeOrderStmt,
+		countActiveOrdersStmt:          q.countActiveOrdersStmt,
+		createAPIKeyStmt:               q.createAPIKeyStmt,
+		createItemStmt:                 q.createItemStmt,
+		createOrderStmt:                q.createOrderStmt,
+		createOrderEventStmt:           q.createOrderEventStmt,
+		deactivateOrderStmt:            q.deactivateOrderStmt,
+		deleteItemByUserStmt:           q.deleteItemByUserStmt,
+		getActiveOrderStmt:             q.getActiveOrderStmt,
+		getActiveOrderForUpdateStmt:    q.getActiveOrderForUpdateStmt,
+		getActiveOrdersWithExpiryStmt:  q.getActiveOrdersWithExpiryStmt,
+		getAPIKeyByHashStmt:            q.getAPIKeyByHashStmt,
+		getAPIKeysByChatIDStmt:         q.getAPIKeysByChatIDStmt,
+		getChatStmt:                    q.getChatStmt,
+		getItemStmt:                    q.getItemStmt,
+		getItemsByOrderIDStmt:          q.getItemsByOrderIDStmt,
+		getItemsWithUsersByOrderIDStmt: q.getItemsWithUsersByOrderIDStmt,
+		getLargeChatIDsStmt:            q.getLargeChatIDsStmt,
+		getLargeUserIDsStmt:            q.getLargeUserIDsStmt,
+		getOrderByIDStmt:               q.getOrderByIDStmt,
+		getOrdersByChatIDStmt:          q.getOrdersByChatIDStmt,
+		getRecentOrderEventsStmt:       q.getRecentOrderEventsStmt,
+		getUserStmt:                    q.getUserStmt,
+		getUserItemsStmt:               q.getUserItemsStmt,
+		migrateChatIDStmt:              q.migrateChatIDStmt,
+		relinkWrappedUserIDStmt:        q.relinkWrappedUserIDStmt,
+		revokeAPIKeyStmt:               q.revokeAPIKeyStmt,
+		setChatLanguageStmt:            q.setChatLanguageStmt,
+		updateExpiryStmt:               q.updateExpiryStmt,
+		updateItemQuantityStmt:         q.updateItemQuantityStmt,
+		updateReminderStmt:             q.updateReminderStmt,
+		upsertChatStmt:                 q.upsertChatStmt,
+		upsertUserStmt:                 q.upsertUserStmt,
 	}
 }
@@ -8,17 +8,16 @@ import (
 )
 
 const createItem = `-- name: CreateItem :one
-INSERT INTO items (order_id, quantity, name, user_id, user_name)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_id, user_name, order_id, quantity, name
+INSERT INTO items (order_id, quantity, name, user_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, order_id, quantity, name, deleted_at
 `
 
 type CreateItemParams struct {
 	OrderID  int32  `json:"order_id"`
 	Quantity int32  `json:"quantity"`
 	Name     string `json:"name"`
-	UserID   int32  `json:"user_id"`
-	UserName string `json:"user_name"`
+	UserID   int64  `json:"user_id"`
 }
 
 func (q *Queries) CreateItem(ctx context.Context, arg CreateItemParams) (Item, error) {
@@ -27,29 +26,30 @@ func (q *Queries) CreateItem(ctx context.Context, arg CreateItemParams) (Item, e
 		arg.Quantity,
 		arg.Name,
 		arg.UserID,
-		arg.UserName,
 	)
 	var i Item
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.UserName,
 		&i.OrderID,
 		&i.Quantity,
 		&i.Name,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteItemByUser = `-- name: DeleteItemByUser :one
-DELETE FROM items
+UPDATE items
+SET deleted_at = NOW()
 WHERE id = $1 AND user_id = $2
-RETURNING id, user_id, user_name, order_id, quantity, name
+AND deleted_at IS NULL
+RETURNING id, user_id, order_id, quantity, name, deleted_at
 `
 
 type DeleteItemByUserParams struct {
 	ID     int32 `json:"id"`
-	UserID int32 `json:"user_id"`
+	UserID int64 `json:"user_id"`
 }
 
 func (q *Queries) DeleteItemByUser(ctx context.Context, arg DeleteItemByUserParams) (Item, error) {
@@ -58,24 +58,25 @@ func (q *Queries) DeleteItemByUser(ctx context.Context, arg DeleteItemByUserPara
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.UserName,
 		&i.OrderID,
 		&i.Quantity,
 		&i.Name,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getItem = `-- name: GetItem :one
-SELECT id, user_id, user_name, order_id, quantity, name FROM items
+SELECT id, user_id, order_id, quantity, name, deleted_at FROM items
 WHERE order_id = $1
 AND user_id = $2
 AND LOWER(name) = LOWER($3)
+AND deleted_at IS NULL
 `
 
 type GetItemParams struct {
 	OrderID int32  `json:"order_id"`
-	UserID  int32  `json:"user_id"`
+	UserID  int64  `json:"user_id"`
 	Lower   string `json:"lower"`
 }
 
@@ -85,17 +86,18 @@ func (q *Queries) GetItem(ctx context.Context, arg GetItemParams) (Item, error)
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.UserName,
 		&i.OrderID,
 		&i.Quantity,
 		&i.Name,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getItemsByOrderID = `-- name: GetItemsByOrderID :many
-SELECT id, user_id, user_name, order_id, quantity, name FROM items
+SELECT id, user_id, order_id, quantity, name, deleted_at FROM items
 WHERE order_id = $1
+AND deleted_at IS NULL
 `
 
 func (q *Queries) GetItemsByOrderID(ctx context.Context, orderID int32) ([]Item, error) {
@@ -110,10 +112,10 @@ func (q *Queries) GetItemsByOrderID(ctx context.Context, orderID int32) ([]Item,
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
-			&i.UserName,
 			&i.OrderID,
 			&i.Quantity,
 			&i.Name,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -129,12 +131,13 @@ func (q *Queries) GetItemsByOrderID(ctx context.Context, orderID int32) ([]Item,
 }
 
 const getUserItems = `-- name: GetUserItems :many
-SELECT id, user_id, user_name, order_id, quantity, name FROM items
+SELECT id, user_id, order_id, quantity, name, deleted_at FROM items
 WHERE user_id = $1 AND order_id = $2
+AND deleted_at IS NULL
 `
 
 type GetUserItemsParams struct {
-	UserID  int32 `json:"user_id"`
+	UserID  int64 `json:"user_id"`
 	OrderID int32 `json:"order_id"`
 }
 
@@ -150,10 +153,10 @@ func (q *Queries) GetUserItems(ctx context.Context, arg GetUserItemsParams) ([]I
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
-			&i.UserName,
 			&i.OrderID,
 			&i.Quantity,
 			&i.Name,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -174,12 +177,13 @@ SET quantity = $4
 WHERE order_id = $1
 AND user_id = $2
 AND LOWER(name) = LOWER($3)
-RETURNING id, user_id, user_name, order_id, quantity, name
+AND deleted_at IS NULL
+RETURNING id, user_id, order_id, quantity, name, deleted_at
 `
 
 type UpdateItemQuantityParams struct {
 	OrderID  int32  `json:"order_id"`
-	UserID   int32  `json:"user_id"`
+	UserID   int64  `json:"user_id"`
 	Lower    string `json:"lower"`
 	Quantity int32  `json:"quantity"`
 }
@@ -195,10 +199,129 @@ func (q *Queries) UpdateItemQuantity(ctx context.Context, arg UpdateItemQuantity
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.UserName,
 		&i.OrderID,
 		&i.Quantity,
 		&i.Name,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const relinkWrappedUserID = `-- name: RelinkWrappedUserID :execrows
+WITH merged AS (
+  UPDATE items
+  SET quantity = items.quantity + wrapped.quantity
+  FROM items wrapped
+  WHERE wrapped.user_id = $1
+  AND wrapped.deleted_at IS NULL
+  AND items.user_id = $2
+  AND items.order_id = wrapped.order_id
+  AND LOWER(items.name) = LOWER(wrapped.name)
+  AND items.deleted_at IS NULL
+  RETURNING wrapped.id
+)
+UPDATE items
+SET user_id = $2,
+  deleted_at = CASE WHEN items.id IN (SELECT id FROM merged) THEN NOW() ELSE items.deleted_at END
+WHERE items.user_id = $1
+`
+
+type RelinkWrappedUserIDParams struct {
+	WrappedUserID int64 `json:"wrapped_user_id"`
+	UserID        int64 `json:"user_id"`
+}
+
+func (q *Queries) RelinkWrappedUserID(ctx context.Context, arg RelinkWrappedUserIDParams) (int64, error) {
+	result, err := q.exec(ctx, q.relinkWrappedUserIDStmt, relinkWrappedUserID, arg.WrappedUserID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getItemsWithUsersByOrderID = `-- name: GetItemsWithUsersByOrderID :many
+SELECT items.id, items.user_id, items.order_id, items.quantity, items.name,
+  users.first_name, users.last_name, users.username
+FROM items
+JOIN users ON users.id = items.user_id
+WHERE items.order_id = $1
+AND items.deleted_at IS NULL
+ORDER BY items.id
+`
+
+type GetItemsWithUsersByOrderIDRow struct {
+	ID        int32  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	OrderID   int32  `json:"order_id"`
+	Quantity  int32  `json:"quantity"`
+	Name      string `json:"name"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) GetItemsWithUsersByOrderID(ctx context.Context, orderID int32) ([]GetItemsWithUsersByOrderIDRow, error) {
+	rows, err := q.query(ctx, q.getItemsWithUsersByOrderIDStmt, getItemsWithUsersByOrderID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetItemsWithUsersByOrderIDRow
+	for rows.Next() {
+		var i GetItemsWithUsersByOrderIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.OrderID,
+			&i.Quantity,
+			&i.Name,
+			&i.FirstName,
+			&i.LastName,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const addItemQuantity = `-- name: AddItemQuantity :one
+INSERT INTO items (order_id, user_id, name, quantity)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (order_id, user_id, (LOWER(name))) WHERE deleted_at IS NULL DO UPDATE
+SET quantity = items.quantity + EXCLUDED.quantity
+RETURNING id, user_id, order_id, quantity, name, deleted_at
+`
+
+type AddItemQuantityParams struct {
+	OrderID  int32  `json:"order_id"`
+	UserID   int64  `json:"user_id"`
+	Name     string `json:"name"`
+	Quantity int32  `json:"quantity"`
+}
+
+func (q *Queries) AddItemQuantity(ctx context.Context, arg AddItemQuantityParams) (Item, error) {
+	row := q.queryRow(ctx, q.addItemQuantityStmt, addItemQuantity,
+		arg.OrderID,
+		arg.UserID,
+		arg.Name,
+		arg.Quantity,
+	)
+	var i Item
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OrderID,
+		&i.Quantity,
+		&i.Name,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,732 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gpng/order-bot/sqlc/models"
+	"github.com/lib/pq"
+)
+
+// errors matching the postgres constraint violations, see models.IsUniqueViolation and models.IsForeignKeyViolation
+var (
+	ErrForeignKeyViolation = &pq.Error{Code: "23503", Message: "violates foreign key constraint"}
+	ErrUniqueViolation     = &pq.Error{Code: "23505", Message: "violates unique constraint"}
+)
+
+// Queries is an in-memory models.Store with the same semantics as the sql queries, for tests
+type Queries struct {
+	tx      sync.Mutex
+	mu      sync.Mutex
+	orders  []models.Order
+	items   []models.Item
+	apiKeys []models.ApiKey
+	events  []models.OrderEvent
+	users   map[int64]models.UserProfile
+	chats   map[int64]models.ChatProfile
+	serials map[string]int32
+}
+
+var _ models.Store = (*Queries)(nil)
+
+// New empty in-memory querier
+func New() *Queries {
+	return &Queries{
+		users:   map[int64]models.UserProfile{},
+		chats:   map[int64]models.ChatProfile{},
+		serials: map[string]int32{},
+	}
+}
+
+// nextID mimics a SERIAL column
+func (q *Queries) nextID(table string) int32 {
+	q.serials[table]++
+	return q.serials[table]
+}
+
+// timestamp mimics a TIMESTAMP column, which keeps the wall clock and drops the time zone
+func timestamp(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone("", 0)).
+		Round(time.Microsecond)
+}
+
+func nullTimestamp(t sql.NullTime) sql.NullTime {
+	if !t.Valid {
+		return t
+	}
+	return sql.NullTime{Time: timestamp(t.Time), Valid: true}
+}
+
+// ExecTx runs fn with the queries, restoring the previous state if fn returns an error.
+// Units of work run one at a time, but are not isolated from queries outside of them.
+func (q *Queries) ExecTx(ctx context.Context, fn func(models.Querier) error) error {
+	q.tx.Lock()
+	defer q.tx.Unlock()
+
+	q.mu.Lock()
+	snapshot := q.copy()
+	q.mu.Unlock()
+
+	if err := fn(q); err != nil {
+		q.mu.Lock()
+		q.restore(snapshot)
+		q.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// AddItemQuantity adds to the quantity of the user's item with the same name case-insensitively, or creates it
+func (q *Queries) AddItemQuantity(ctx context.Context, arg models.AddItemQuantityParams) (models.Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.order(arg.OrderID); !ok {
+		return models.Item{}, ErrForeignKeyViolation
+	}
+	if _, ok := q.users[arg.UserID]; !ok {
+		return models.Item{}, ErrForeignKeyViolation
+	}
+	for i, item := range q.items {
+		if !item.DeletedAt.Valid && item.OrderID == arg.OrderID && item.UserID == arg.UserID && strings.EqualFold(item.Name, arg.Name) {
+			q.items[i].Quantity += arg.Quantity
+			return q.items[i], nil
+		}
+	}
+	item := models.Item{
+		ID:       q.nextID("items"),
+		UserID:   arg.UserID,
+		OrderID:  arg.OrderID,
+		Quantity: arg.Quantity,
+		Name:     arg.Name,
+	}
+	q.items = append(q.items, item)
+	return item, nil
+}
+
+// CancelOrder deactivates the chat's active orders and returns the first
+func (q *Queries) CancelOrder(ctx context.Context, chatID int64) (models.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var cancelled []models.Order
+	for i := range q.orders {
+		if q.orders[i].ChatID == chatID && q.orders[i].Active {
+			q.orders[i].Active = false
+			cancelled = append(cancelled, q.orders[i])
+		}
+	}
+	if len(cancelled) == 0 {
+		return models.Order{}, sql.ErrNoRows
+	}
+	return cancelled[0], nil
+}
+
+// CloseOrder only returns the order if it was active
+func (q *Queries) CloseOrder(ctx context.Context, id int32) (models.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.orders {
+		if q.orders[i].ID == id && q.orders[i].Active {
+			q.orders[i].Active = false
+			return q.orders[i], nil
+		}
+	}
+	return models.Order{}, sql.ErrNoRows
+}
+
+// CreateItem fails if the order or user does not exist, or the user has an item with the same name
+func (q *Queries) CreateItem(ctx context.Context, arg models.CreateItemParams) (models.Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.order(arg.OrderID); !ok {
+		return models.Item{}, ErrForeignKeyViolation
+	}
+	if _, ok := q.users[arg.UserID]; !ok {
+		return models.Item{}, ErrForeignKeyViolation
+	}
+	for _, item := range q.items {
+		if !item.DeletedAt.Valid && item.OrderID == arg.OrderID && item.UserID == arg.UserID && strings.EqualFold(item.Name, arg.Name) {
+			return models.Item{}, ErrUniqueViolation
+		}
+	}
+	item := models.Item{
+		ID:       q.nextID("items"),
+		UserID:   arg.UserID,
+		OrderID:  arg.OrderID,
+		Quantity: arg.Quantity,
+		Name:     arg.Name,
+	}
+	q.items = append(q.items, item)
+	return item, nil
+}
+
+// CreateOrder is active with no scheduled jobs, and fails if the chat has an active order
+func (q *Queries) CreateOrder(ctx context.Context, arg models.CreateOrderParams) (models.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.hasActiveOrder(arg.ChatID) {
+		return models.Order{}, ErrUniqueViolation
+	}
+	order := models.Order{
+		ID:           q.nextID("orders"),
+		ChatID:       arg.ChatID,
+		Title:        arg.Title,
+		Expiry:       nullTimestamp(arg.Expiry),
+		Active:       true,
+		LanguageCode: arg.LanguageCode,
+	}
+	q.orders = append(q.orders, order)
+	return order, nil
+}
+
+// DeactivateOrder does nothing if the order does not exist
+func (q *Queries) DeactivateOrder(ctx context.Context, id int32) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.orders {
+		if q.orders[i].ID == id {
+			q.orders[i].Active = false
+		}
+	}
+	return nil
+}
+
+// DeleteItemByUser soft deletes the item and returns it
+func (q *Queries) DeleteItemByUser(ctx context.Context, arg models.DeleteItemByUserParams) (models.Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, item := range q.items {
+		if item.ID == arg.ID && item.UserID == arg.UserID && !item.DeletedAt.Valid {
+			q.items[i].DeletedAt = sql.NullTime{Time: timestamp(time.Now()), Valid: true}
+			return q.items[i], nil
+		}
+	}
+	return models.Item{}, sql.ErrNoRows
+}
+
+// GetActiveOrder of the chat
+func (q *Queries) GetActiveOrder(ctx context.Context, chatID int64) (models.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, order := range q.orders {
+		if order.ChatID == chatID && order.Active {
+			return order, nil
+		}
+	}
+	return models.Order{}, sql.ErrNoRows
+}
+
+// GetActiveOrderForUpdate is GetActiveOrder, as transactions already run one at a time
+func (q *Queries) GetActiveOrderForUpdate(ctx context.Context, chatID int64) (models.Order, error) {
+	return q.GetActiveOrder(ctx, chatID)
+}
+
+// GetActiveOrdersWithExpiry ordered by id
+func (q *Queries) GetActiveOrdersWithExpiry(ctx context.Context) ([]models.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var orders []models.Order
+	for _, order := range q.orders {
+		if order.Active && order.Expiry.Valid {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
+// CountActiveOrders of all chats
+func (q *Queries) CountActiveOrders(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var count int64
+	for _, order := range q.orders {
+		if order.Active {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetOrdersByChatID newest first
+func (q *Queries) GetOrdersByChatID(ctx context.Context, chatID int64) ([]models.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var orders []models.Order
+	for i := len(q.orders) - 1; i >= 0; i-- {
+		if q.orders[i].ChatID == chatID {
+			orders = append(orders, q.orders[i])
+		}
+	}
+	return orders, nil
+}
+
+// GetItem matches name case-insensitively
+func (q *Queries) GetItem(ctx context.Context, arg models.GetItemParams) (models.Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, item := range q.items {
+		if !item.DeletedAt.Valid && item.OrderID == arg.OrderID && item.UserID == arg.UserID && strings.EqualFold(item.Name, arg.Lower) {
+			return item, nil
+		}
+	}
+	return models.Item{}, sql.ErrNoRows
+}
+
+// GetItemsByOrderID returns nil if there are no items
+func (q *Queries) GetItemsByOrderID(ctx context.Context, orderID int32) ([]models.Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var items []models.Item
+	for _, item := range q.items {
+		if item.OrderID == orderID && !item.DeletedAt.Valid {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// GetItemsWithUsersByOrderID joins the current profile of each item's user
+func (q *Queries) GetItemsWithUsersByOrderID(ctx context.Context, orderID int32) ([]models.GetItemsWithUsersByOrderIDRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var items []models.GetItemsWithUsersByOrderIDRow
+	for _, item := range q.items {
+		user, ok := q.users[item.UserID]
+		if item.OrderID != orderID || item.DeletedAt.Valid || !ok {
+			continue
+		}
+		items = append(items, models.GetItemsWithUsersByOrderIDRow{
+			ID:        item.ID,
+			UserID:    item.UserID,
+			OrderID:   item.OrderID,
+			Quantity:  item.Quantity,
+			Name:      item.Name,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Username:  user.Username,
+		})
+	}
+	return items, nil
+}
+
+// GetOrderByID including inactive orders
+func (q *Queries) GetOrderByID(ctx context.Context, id int32) (models.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	order, ok := q.order(id)
+	if !ok {
+		return models.Order{}, sql.ErrNoRows
+	}
+	return *order, nil
+}
+
+// GetUserItems returns nil if there are no items
+func (q *Queries) GetUserItems(ctx context.Context, arg models.GetUserItemsParams) ([]models.Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var items []models.Item
+	for _, item := range q.items {
+		if item.UserID == arg.UserID && item.OrderID == arg.OrderID && !item.DeletedAt.Valid {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// UpdateExpiry does nothing if the order does not exist
+func (q *Queries) UpdateExpiry(ctx context.Context, arg models.UpdateExpiryParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if order, ok := q.order(arg.ID); ok {
+		order.ExpiryRunAt = arg.ExpiryRunAt
+		order.ExpiryID = arg.ExpiryID
+	}
+	return nil
+}
+
+// UpdateItemQuantity sets the quantity of matching items, matching name case-insensitively, and returns the first
+func (q *Queries) UpdateItemQuantity(ctx context.Context, arg models.UpdateItemQuantityParams) (models.Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var updated []models.Item
+	for i, item := range q.items {
+		if !item.DeletedAt.Valid && item.OrderID == arg.OrderID && item.UserID == arg.UserID && strings.EqualFold(item.Name, arg.Lower) {
+			q.items[i].Quantity = arg.Quantity
+			updated = append(updated, q.items[i])
+		}
+	}
+	if len(updated) == 0 {
+		return models.Item{}, sql.ErrNoRows
+	}
+	return updated[0], nil
+}
+
+// UpdateReminder does nothing if the order does not exist
+func (q *Queries) UpdateReminder(ctx context.Context, arg models.UpdateReminderParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if order, ok := q.order(arg.ID); ok {
+		order.ReminderRunAt = arg.ReminderRunAt
+		order.ReminderID = arg.ReminderID
+	}
+	return nil
+}
+
+// CreateAPIKey fails if the hash already exists
+func (q *Queries) CreateAPIKey(ctx context.Context, arg models.CreateAPIKeyParams) (models.ApiKey, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, apiKey := range q.apiKeys {
+		if apiKey.KeyHash == arg.KeyHash {
+			return models.ApiKey{}, ErrUniqueViolation
+		}
+	}
+	apiKey := models.ApiKey{
+		ID:        q.nextID("api_keys"),
+		ChatID:    arg.ChatID,
+		CreatedBy: arg.CreatedBy,
+		KeyHash:   arg.KeyHash,
+		CreatedAt: timestamp(time.Now()),
+	}
+	q.apiKeys = append(q.apiKeys, apiKey)
+	return apiKey, nil
+}
+
+// GetAPIKeyByHash excluding revoked keys
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.ApiKey, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, apiKey := range q.apiKeys {
+		if apiKey.KeyHash == keyHash && !apiKey.RevokedAt.Valid {
+			return apiKey, nil
+		}
+	}
+	return models.ApiKey{}, sql.ErrNoRows
+}
+
+// GetAPIKeysByChatID excluding revoked keys, ordered by id
+func (q *Queries) GetAPIKeysByChatID(ctx context.Context, chatID int64) ([]models.ApiKey, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var apiKeys []models.ApiKey
+	for _, apiKey := range q.apiKeys {
+		if apiKey.ChatID == chatID && !apiKey.RevokedAt.Valid {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	return apiKeys, nil
+}
+
+// MigrateChatID moves orders and api keys to the new chat id, an active order stays if the new chat has one.
+// The chat is copied to the new chat id, keeping its language unless the new chat has one.
+func (q *Queries) MigrateChatID(ctx context.Context, arg models.MigrateChatIDParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if old, ok := q.chats[arg.OldChatID]; ok {
+		now := timestamp(time.Now())
+		chat, ok := q.chats[arg.ChatID]
+		if !ok {
+			chat = old
+			chat.ID = arg.ChatID
+			chat.CreatedAt = now
+			chat.UpdatedAt = now
+		} else if chat.Language == "" {
+			chat.Language = old.Language
+			chat.UpdatedAt = now
+		}
+		q.chats[arg.ChatID] = chat
+	}
+	hasActive := q.hasActiveOrder(arg.ChatID)
+	for i, order := range q.orders {
+		if order.ChatID == arg.OldChatID && (!order.Active || !hasActive) {
+			q.orders[i].ChatID = arg.ChatID
+		}
+	}
+	for i, apiKey := range q.apiKeys {
+		if apiKey.ChatID == arg.OldChatID {
+			q.apiKeys[i].ChatID = arg.ChatID
+		}
+	}
+	return nil
+}
+
+// RelinkWrappedUserID moves items of the wrapped user id to the user, which must exist.
+// Items the user already has in the same order are merged, leaving the wrapped one deleted.
+func (q *Queries) RelinkWrappedUserID(ctx context.Context, arg models.RelinkWrappedUserIDParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var moved []int
+	for i, item := range q.items {
+		if item.UserID == arg.WrappedUserID {
+			moved = append(moved, i)
+		}
+	}
+	if len(moved) == 0 {
+		return 0, nil
+	}
+	if _, ok := q.users[arg.UserID]; !ok {
+		return 0, ErrForeignKeyViolation
+	}
+	now := nullTimestamp(sql.NullTime{Time: time.Now(), Valid: true})
+	for _, i := range moved {
+		wrapped := q.items[i]
+		if !wrapped.DeletedAt.Valid {
+			for j, item := range q.items {
+				if item.UserID == arg.UserID && item.OrderID == wrapped.OrderID &&
+					strings.EqualFold(item.Name, wrapped.Name) && !item.DeletedAt.Valid {
+					q.items[j].Quantity += wrapped.Quantity
+					q.items[i].DeletedAt = now
+					break
+				}
+			}
+		}
+		q.items[i].UserID = arg.UserID
+	}
+	return int64(len(moved)), nil
+}
+
+// RevokeAPIKey returns the revoked key
+func (q *Queries) RevokeAPIKey(ctx context.Context, arg models.RevokeAPIKeyParams) (models.ApiKey, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, apiKey := range q.apiKeys {
+		if apiKey.ID == arg.ID && apiKey.ChatID == arg.ChatID && !apiKey.RevokedAt.Valid {
+			q.apiKeys[i].RevokedAt = sql.NullTime{Time: timestamp(time.Now()), Valid: true}
+			return q.apiKeys[i], nil
+		}
+	}
+	return models.ApiKey{}, sql.ErrNoRows
+}
+
+// GetUser by telegram user id
+func (q *Queries) GetUser(ctx context.Context, id int64) (models.UserProfile, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	user, ok := q.users[id]
+	if !ok {
+		return models.UserProfile{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// GetLargeUserIDs of users that do not fit in 32 bits
+func (q *Queries) GetLargeUserIDs(ctx context.Context) ([]int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	seen := map[int64]bool{}
+	for id := range q.users {
+		seen[id] = true
+	}
+	return largeIDs(seen), nil
+}
+
+// largeIDs of ids in ascending order
+func largeIDs(ids map[int64]bool) []int64 {
+	var large []int64
+	for id := range ids {
+		if id != int64(int32(id)) {
+			large = append(large, id)
+		}
+	}
+	sort.Slice(large, func(i, j int) bool { return large[i] < large[j] })
+	return large
+}
+
+// UpsertUser only touches updated_at when the profile changed
+func (q *Queries) UpsertUser(ctx context.Context, arg models.UpsertUserParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := timestamp(time.Now())
+	user, ok := q.users[arg.ID]
+	if !ok {
+		user = models.UserProfile{ID: arg.ID, CreatedAt: now}
+	} else if user.IsBot == arg.IsBot && user.FirstName == arg.FirstName && user.LastName == arg.LastName &&
+		user.Username == arg.Username && user.LanguageCode == arg.LanguageCode {
+		return nil
+	}
+	user.IsBot = arg.IsBot
+	user.FirstName = arg.FirstName
+	user.LastName = arg.LastName
+	user.Username = arg.Username
+	user.LanguageCode = arg.LanguageCode
+	user.UpdatedAt = now
+	q.users[arg.ID] = user
+	return nil
+}
+
+// GetChat by telegram chat id
+func (q *Queries) GetChat(ctx context.Context, id int64) (models.ChatProfile, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	chat, ok := q.chats[id]
+	if !ok {
+		return models.ChatProfile{}, sql.ErrNoRows
+	}
+	return chat, nil
+}
+
+// GetLargeChatIDs of chats and api keys that do not fit in 32 bits
+func (q *Queries) GetLargeChatIDs(ctx context.Context) ([]int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	seen := map[int64]bool{}
+	for id := range q.chats {
+		seen[id] = true
+	}
+	for _, apiKey := range q.apiKeys {
+		seen[apiKey.ChatID] = true
+	}
+	return largeIDs(seen), nil
+}
+
+// SetChatLanguage creates the chat if it does not exist
+func (q *Queries) SetChatLanguage(ctx context.Context, arg models.SetChatLanguageParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := timestamp(time.Now())
+	chat, ok := q.chats[arg.ID]
+	if !ok {
+		chat = models.ChatProfile{ID: arg.ID, CreatedAt: now}
+	}
+	chat.Language = arg.Language
+	chat.UpdatedAt = now
+	q.chats[arg.ID] = chat
+	return nil
+}
+
+// UpsertChat only touches updated_at when the profile changed
+func (q *Queries) UpsertChat(ctx context.Context, arg models.UpsertChatParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := timestamp(time.Now())
+	chat, ok := q.chats[arg.ID]
+	if !ok {
+		chat = models.ChatProfile{ID: arg.ID, CreatedAt: now}
+	} else if chat.Type == arg.Type && chat.Title == arg.Title && chat.Username == arg.Username {
+		return nil
+	}
+	chat.Type = arg.Type
+	chat.Title = arg.Title
+	chat.Username = arg.Username
+	chat.UpdatedAt = now
+	q.chats[arg.ID] = chat
+	return nil
+}
+
+// CreateOrderEvent fails if the order, user or item does not exist
+func (q *Queries) CreateOrderEvent(ctx context.Context, arg models.CreateOrderEventParams) (models.OrderEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.order(arg.OrderID); !ok {
+		return models.OrderEvent{}, ErrForeignKeyViolation
+	}
+	if _, ok := q.users[arg.UserID.Int64]; arg.UserID.Valid && !ok {
+		return models.OrderEvent{}, ErrForeignKeyViolation
+	}
+	if arg.ItemID.Valid && !q.hasItem(arg.ItemID.Int32) {
+		return models.OrderEvent{}, ErrForeignKeyViolation
+	}
+	event := models.OrderEvent{
+		ID:        int64(q.nextID("order_events")),
+		OrderID:   arg.OrderID,
+		Type:      arg.Type,
+		UserID:    arg.UserID,
+		ItemID:    arg.ItemID,
+		ItemName:  arg.ItemName,
+		Quantity:  arg.Quantity,
+		UpdateID:  arg.UpdateID,
+		CreatedAt: timestamp(arg.CreatedAt),
+	}
+	q.events = append(q.events, event)
+	return event, nil
+}
+
+// GetRecentOrderEvents newest first, joining the current profile of each event's user
+func (q *Queries) GetRecentOrderEvents(ctx context.Context, arg models.GetRecentOrderEventsParams) ([]models.GetRecentOrderEventsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var events []models.GetRecentOrderEventsRow
+	for i := len(q.events) - 1; i >= 0 && len(events) < int(arg.Limit); i-- {
+		event := q.events[i]
+		if event.OrderID != arg.OrderID {
+			continue
+		}
+		row := models.GetRecentOrderEventsRow{
+			ID:        event.ID,
+			Type:      event.Type,
+			UserID:    event.UserID,
+			ItemName:  event.ItemName,
+			Quantity:  event.Quantity,
+			CreatedAt: event.CreatedAt,
+		}
+		if user, ok := q.users[event.UserID.Int64]; event.UserID.Valid && ok {
+			row.FirstName = sql.NullString{String: user.FirstName, Valid: true}
+			row.LastName = sql.NullString{String: user.LastName, Valid: true}
+			row.Username = sql.NullString{String: user.Username, Valid: true}
+		}
+		events = append(events, row)
+	}
+	return events, nil
+}
+
+// hasItem must be called with the lock held, deleted items included
+func (q *Queries) hasItem(id int32) bool {
+	for _, item := range q.items {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// hasActiveOrder must be called with the lock held
+func (q *Queries) hasActiveOrder(chatID int64) bool {
+	for _, order := range q.orders {
+		if order.ChatID == chatID && order.Active {
+			return true
+		}
+	}
+	return false
+}
+
+// copy must be called with the lock held, serials are kept on restore like postgres sequences
+func (q *Queries) copy() *Queries {
+	c := &Queries{
+		orders:  append([]models.Order(nil), q.orders...),
+		items:   append([]models.Item(nil), q.items...),
+		apiKeys: append([]models.ApiKey(nil), q.apiKeys...),
+		events:  append([]models.OrderEvent(nil), q.events...),
+		users:   map[int64]models.UserProfile{},
+		chats:   map[int64]models.ChatProfile{},
+	}
+	for id, user := range q.users {
+		c.users[id] = user
+	}
+	for id, chat := range q.chats {
+		c.chats[id] = chat
+	}
+	return c
+}
+
+// restore must be called with the lock held
+func (q *Queries) restore(c *Queries) {
+	q.orders = c.orders
+	q.items = c.items
+	q.apiKeys = c.apiKeys
+	q.events = c.events
+	q.users = c.users
+	q.chats = c.chats
+}
+
+// order must be called with the lock held
+func (q *Queries) order(id int32) (*models.Order, bool) {
+	for i := range q.orders {
+		if q.orders[i].ID == id {
+			return &q.orders[i], true
+		}
+	}
+	return nil, false
+}
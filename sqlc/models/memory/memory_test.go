@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/gpng/order-bot/sqlc/models"
+	"github.com/gpng/order-bot/sqlc/models/memory"
+	"github.com/gpng/order-bot/sqlc/models/modelstest"
+)
+
+func TestQuerier(t *testing.T) {
+	modelstest.TestQuerier(t, func(t *testing.T) models.Store {
+		return memory.New()
+	})
+}
@@ -4,20 +4,41 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 )
 
+type ApiKey struct {
+	ID        int32        `json:"id"`
+	ChatID    int64        `json:"chat_id"`
+	CreatedBy int64        `json:"created_by"`
+	KeyHash   string       `json:"key_hash"`
+	CreatedAt time.Time    `json:"created_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+}
+
+type ChatProfile struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Language  string    `json:"language"`
+}
+
 type Item struct {
-	ID       int32  `json:"id"`
-	UserID   int32  `json:"user_id"`
-	UserName string `json:"user_name"`
-	OrderID  int32  `json:"order_id"`
-	Quantity int32  `json:"quantity"`
-	Name     string `json:"name"`
+	ID        int32        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	OrderID   int32        `json:"order_id"`
+	Quantity  int32        `json:"quantity"`
+	Name      string       `json:"name"`
+	DeletedAt sql.NullTime `json:"deleted_at"`
 }
 
 type Order struct {
 	ID            int32          `json:"id"`
-	ChatID        int32          `json:"chat_id"`
+	ChatID        int64          `json:"chat_id"`
 	Title         string         `json:"title"`
 	Expiry        sql.NullTime   `json:"expiry"`
 	Active        bool           `json:"active"`
@@ -25,4 +46,45 @@ type Order struct {
 	ReminderID    sql.NullString `json:"reminder_id"`
 	ExpiryRunAt   sql.NullInt64  `json:"expiry_run_at"`
 	ExpiryID      sql.NullString `json:"expiry_id"`
+	LanguageCode  string         `json:"language_code"`
+}
+
+type OrderEvent struct {
+	ID        int64         `json:"id"`
+	OrderID   int32         `json:"order_id"`
+	Type      string        `json:"type"`
+	UserID    sql.NullInt64 `json:"user_id"`
+	ItemID    sql.NullInt32 `json:"item_id"`
+	ItemName  string        `json:"item_name"`
+	Quantity  int32         `json:"quantity"`
+	UpdateID  sql.NullInt64 `json:"update_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type ProcessedUpdate struct {
+	UpdateID    int64 `json:"update_id"`
+	ProcessedAt int64 `json:"processed_at"`
+}
+
+type ScheduledJob struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Args        json.RawMessage `json:"args"`
+	RunAt       int64           `json:"run_at"`
+	Attempts    int32           `json:"attempts"`
+	LockedUntil sql.NullInt64   `json:"locked_until"`
+	FailedAt    sql.NullInt64   `json:"failed_at"`
+	LastError   sql.NullString  `json:"last_error"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type UserProfile struct {
+	ID           int64     `json:"id"`
+	IsBot        bool      `json:"is_bot"`
+	FirstName    string    `json:"first_name"`
+	LastName     string    `json:"last_name"`
+	Username     string    `json:"username"`
+	LanguageCode string    `json:"language_code"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
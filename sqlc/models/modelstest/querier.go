@@ -0,0 +1,828 @@
+package modelstest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gpng/order-bot/sqlc/models"
+)
+
+// NewQuerier returns an empty store for a single test
+type NewQuerier func(t *testing.T) models.Store
+
+// TestQuerier is the conformance suite every models.Store implementation must pass
+func TestQuerier(t *testing.T, newQuerier NewQuerier) {
+	t.Run("Orders", func(t *testing.T) { testOrders(t, newQuerier(t)) })
+	t.Run("OrderExpiry", func(t *testing.T) { testOrderExpiry(t, newQuerier(t)) })
+	t.Run("ActiveOrdersWithExpiry", func(t *testing.T) { testActiveOrdersWithExpiry(t, newQuerier(t)) })
+	t.Run("OrdersByChatID", func(t *testing.T) { testOrdersByChatID(t, newQuerier(t)) })
+	t.Run("CancelOrder", func(t *testing.T) { testCancelOrder(t, newQuerier(t)) })
+	t.Run("CloseOrder", func(t *testing.T) { testCloseOrder(t, newQuerier(t)) })
+	t.Run("Items", func(t *testing.T) { testItems(t, newQuerier(t)) })
+	t.Run("DeleteItemByUser", func(t *testing.T) { testDeleteItemByUser(t, newQuerier(t)) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, newQuerier(t)) })
+	t.Run("TelegramIDs", func(t *testing.T) { testTelegramIDs(t, newQuerier(t)) })
+	t.Run("LargeIDs", func(t *testing.T) { testLargeIDs(t, newQuerier(t)) })
+	t.Run("RelinkWrappedUserID", func(t *testing.T) { testRelinkWrappedUserID(t, newQuerier(t)) })
+	t.Run("MigrateChatID", func(t *testing.T) { testMigrateChatID(t, newQuerier(t)) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, newQuerier(t)) })
+	t.Run("Chats", func(t *testing.T) { testChats(t, newQuerier(t)) })
+	t.Run("ChatLanguage", func(t *testing.T) { testChatLanguage(t, newQuerier(t)) })
+	t.Run("OneActiveOrderPerChat", func(t *testing.T) { testOneActiveOrderPerChat(t, newQuerier(t)) })
+	t.Run("AddItemQuantity", func(t *testing.T) { testAddItemQuantity(t, newQuerier(t)) })
+	t.Run("ExecTx", func(t *testing.T) { testExecTx(t, newQuerier(t)) })
+	t.Run("OrderEvents", func(t *testing.T) { testOrderEvents(t, newQuerier(t)) })
+}
+
+func testOrders(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	if !order.Active || order.ReminderID.Valid || order.ExpiryID.Valid || order.Expiry.Valid {
+		t.Fatalf("unexpected defaults for new order: %+v", order)
+	}
+
+	active, err := q.GetActiveOrder(ctx, 1)
+	if err != nil || active != order {
+		t.Fatalf("GetActiveOrder = %+v, %v, want %+v", active, err, order)
+	}
+	if _, err := q.GetActiveOrder(ctx, 2); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetActiveOrder of other chat err = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := q.GetOrderByID(ctx, order.ID+1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetOrderByID of missing order err = %v, want sql.ErrNoRows", err)
+	}
+
+	err = q.UpdateReminder(ctx, models.UpdateReminderParams{
+		ID:            order.ID,
+		ReminderRunAt: sql.NullInt64{Int64: 100, Valid: true},
+		ReminderID:    sql.NullString{String: "reminder", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("UpdateReminder: %v", err)
+	}
+	err = q.UpdateExpiry(ctx, models.UpdateExpiryParams{
+		ID:          order.ID,
+		ExpiryRunAt: sql.NullInt64{Int64: 200, Valid: true},
+		ExpiryID:    sql.NullString{String: "expiry", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("UpdateExpiry: %v", err)
+	}
+	if err := q.UpdateExpiry(ctx, models.UpdateExpiryParams{ID: order.ID + 1}); err != nil {
+		t.Fatalf("UpdateExpiry of missing order: %v", err)
+	}
+
+	updated, err := q.GetOrderByID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderByID: %v", err)
+	}
+	if updated.ReminderRunAt.Int64 != 100 || updated.ReminderID.String != "reminder" ||
+		updated.ExpiryRunAt.Int64 != 200 || updated.ExpiryID.String != "expiry" {
+		t.Fatalf("scheduled jobs not updated: %+v", updated)
+	}
+
+	if err := q.DeactivateOrder(ctx, order.ID); err != nil {
+		t.Fatalf("DeactivateOrder: %v", err)
+	}
+	if err := q.DeactivateOrder(ctx, order.ID+1); err != nil {
+		t.Fatalf("DeactivateOrder of missing order: %v", err)
+	}
+	if _, err := q.GetActiveOrder(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetActiveOrder after deactivating err = %v, want sql.ErrNoRows", err)
+	}
+	inactive, err := q.GetOrderByID(ctx, order.ID)
+	if err != nil || inactive.Active {
+		t.Fatalf("GetOrderByID after deactivating = %+v, %v", inactive, err)
+	}
+}
+
+func testOrderExpiry(t *testing.T, q models.Querier) {
+	location, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	expiry := time.Date(2021, 1, 31, 23, 30, 0, 0, location)
+
+	order, err := q.CreateOrder(context.Background(), models.CreateOrderParams{
+		ChatID:       1,
+		Title:        "Supper",
+		Expiry:       sql.NullTime{Time: expiry, Valid: true},
+		LanguageCode: "zh-hans",
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if got, err := q.GetOrderByID(context.Background(), order.ID); err != nil || got.LanguageCode != "zh-hans" {
+		t.Fatalf("GetOrderByID = %+v, %v, want language code zh-hans", got, err)
+	}
+
+	// TIMESTAMP columns keep the wall clock and drop the zone
+	_, offset := order.Expiry.Time.Zone()
+	if got := order.Expiry.Time.Format("2006-01-02 15:04"); got != "2021-01-31 23:30" || offset != 0 {
+		t.Fatalf("expiry = %v, want wall clock 2021-01-31 23:30 with zero offset", order.Expiry.Time)
+	}
+}
+
+func testActiveOrdersWithExpiry(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	if orders, err := q.GetActiveOrdersWithExpiry(ctx); err != nil || len(orders) != 0 {
+		t.Fatalf("GetActiveOrdersWithExpiry without orders = %+v, %v, want none", orders, err)
+	}
+
+	expiry := sql.NullTime{Time: time.Date(2021, 3, 1, 12, 30, 0, 0, time.UTC), Valid: true}
+	var withExpiry []models.Order
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		order, err := q.CreateOrder(ctx, models.CreateOrderParams{ChatID: chatID, Title: "Coffeeshop", Expiry: expiry})
+		if err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		withExpiry = append(withExpiry, order)
+	}
+	mustCreateOrder(t, q, 4, "No expiry")
+	if _, err := q.CancelOrder(ctx, 2); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	orders, err := q.GetActiveOrdersWithExpiry(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveOrdersWithExpiry: %v", err)
+	}
+	if len(orders) != 2 || orders[0].ID != withExpiry[0].ID || orders[1].ID != withExpiry[2].ID {
+		t.Fatalf("GetActiveOrdersWithExpiry = %+v, want orders %d and %d", orders, withExpiry[0].ID, withExpiry[2].ID)
+	}
+
+	if count, err := q.CountActiveOrders(ctx); err != nil || count != 3 {
+		t.Fatalf("CountActiveOrders = %d, %v, want 3", count, err)
+	}
+}
+
+func testOrdersByChatID(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	if orders, err := q.GetOrdersByChatID(ctx, 1); err != nil || len(orders) != 0 {
+		t.Fatalf("GetOrdersByChatID without orders = %+v, %v, want none", orders, err)
+	}
+
+	first := mustCreateOrder(t, q, 1, "Coffeeshop")
+	if _, err := q.CancelOrder(ctx, 1); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	mustCreateOrder(t, q, 2, "Bubble tea")
+	second := mustCreateOrder(t, q, 1, "Supper")
+
+	orders, err := q.GetOrdersByChatID(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetOrdersByChatID: %v", err)
+	}
+	if len(orders) != 2 || orders[0].ID != second.ID || orders[1].ID != first.ID || orders[1].Active {
+		t.Fatalf("GetOrdersByChatID = %+v, want orders %d and inactive %d", orders, second.ID, first.ID)
+	}
+}
+
+func testCancelOrder(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	if _, err := q.CancelOrder(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("CancelOrder without active order err = %v, want sql.ErrNoRows", err)
+	}
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	other := mustCreateOrder(t, q, 2, "Bubble tea")
+
+	cancelled, err := q.CancelOrder(ctx, 1)
+	if err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	if cancelled.ID != order.ID || cancelled.Active {
+		t.Fatalf("CancelOrder = %+v, want deactivated order %d", cancelled, order.ID)
+	}
+	if _, err := q.CancelOrder(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("CancelOrder twice err = %v, want sql.ErrNoRows", err)
+	}
+	if active, err := q.GetActiveOrder(ctx, 2); err != nil || active.ID != other.ID {
+		t.Fatalf("CancelOrder affected other chat: %+v, %v", active, err)
+	}
+	if _, err := q.GetActiveOrderForUpdate(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetActiveOrderForUpdate of cancelled order err = %v, want sql.ErrNoRows", err)
+	}
+	if active, err := q.GetActiveOrderForUpdate(ctx, 2); err != nil || active.ID != other.ID {
+		t.Fatalf("GetActiveOrderForUpdate = %+v, %v, want order %d", active, err, other.ID)
+	}
+}
+
+func testCloseOrder(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	closed, err := q.CloseOrder(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("CloseOrder: %v", err)
+	}
+	if closed.ID != order.ID || closed.Active {
+		t.Fatalf("CloseOrder = %+v, want deactivated order %d", closed, order.ID)
+	}
+	if _, err := q.CloseOrder(ctx, order.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("CloseOrder twice err = %v, want sql.ErrNoRows", err)
+	}
+
+	// a later order of the chat is not closed by the earlier order's id
+	next := mustCreateOrder(t, q, 1, "Bubble tea")
+	if _, err := q.CloseOrder(ctx, order.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("CloseOrder of closed order err = %v, want sql.ErrNoRows", err)
+	}
+	if active, err := q.GetActiveOrder(ctx, 1); err != nil || active.ID != next.ID {
+		t.Fatalf("CloseOrder affected the next order: %+v, %v", active, err)
+	}
+}
+
+func testItems(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	other := mustCreateOrder(t, q, 2, "Bubble tea")
+
+	if items, err := q.GetItemsByOrderID(ctx, order.ID); err != nil || len(items) != 0 {
+		t.Fatalf("GetItemsByOrderID without items = %+v, %v", items, err)
+	}
+
+	kopi := mustCreateItem(t, q, order.ID, 10, "Kopi O", 2)
+	teh := mustCreateItem(t, q, order.ID, 20, "teh", 1)
+	mustCreateItem(t, q, other.ID, 10, "kopi o", 5)
+
+	if _, err := q.CreateItem(ctx, models.CreateItemParams{OrderID: other.ID + 100, UserID: 10, Name: "kopi", Quantity: 1}); err == nil {
+		t.Fatalf("CreateItem for missing order succeeded")
+	}
+	if _, err := q.CreateItem(ctx, models.CreateItemParams{OrderID: order.ID, UserID: 30, Name: "kopi", Quantity: 1}); err == nil {
+		t.Fatalf("CreateItem for missing user succeeded")
+	}
+
+	item, err := q.GetItem(ctx, models.GetItemParams{OrderID: order.ID, UserID: 10, Lower: "KOPI o"})
+	if err != nil || item != kopi {
+		t.Fatalf("GetItem = %+v, %v, want %+v", item, err, kopi)
+	}
+	if _, err := q.GetItem(ctx, models.GetItemParams{OrderID: order.ID, UserID: 20, Lower: "kopi o"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetItem of other user err = %v, want sql.ErrNoRows", err)
+	}
+
+	updated, err := q.UpdateItemQuantity(ctx, models.UpdateItemQuantityParams{OrderID: order.ID, UserID: 10, Lower: "kopi O", Quantity: 3})
+	if err != nil {
+		t.Fatalf("UpdateItemQuantity: %v", err)
+	}
+	if updated.ID != kopi.ID || updated.Quantity != 3 || updated.Name != "Kopi O" {
+		t.Fatalf("UpdateItemQuantity = %+v, want quantity 3 with name unchanged", updated)
+	}
+	if _, err := q.UpdateItemQuantity(ctx, models.UpdateItemQuantityParams{OrderID: order.ID, UserID: 10, Lower: "milo", Quantity: 1}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("UpdateItemQuantity of missing item err = %v, want sql.ErrNoRows", err)
+	}
+
+	items, err := q.GetItemsByOrderID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetItemsByOrderID: %v", err)
+	}
+	// there is no ORDER BY, so only the set of items is compared
+	sortItems(items)
+	kopi.Quantity = 3
+	if len(items) != 2 || items[0] != kopi || items[1] != teh {
+		t.Fatalf("GetItemsByOrderID = %+v, want %+v and %+v", items, kopi, teh)
+	}
+
+	userItems, err := q.GetUserItems(ctx, models.GetUserItemsParams{UserID: 20, OrderID: order.ID})
+	if err != nil || len(userItems) != 1 || userItems[0] != teh {
+		t.Fatalf("GetUserItems = %+v, %v, want %+v", userItems, err, teh)
+	}
+	if userItems, err := q.GetUserItems(ctx, models.GetUserItemsParams{UserID: 30, OrderID: order.ID}); err != nil || len(userItems) != 0 {
+		t.Fatalf("GetUserItems without items = %+v, %v", userItems, err)
+	}
+}
+
+func testDeleteItemByUser(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	kopi := mustCreateItem(t, q, order.ID, 10, "kopi", 2)
+
+	if _, err := q.DeleteItemByUser(ctx, models.DeleteItemByUserParams{ID: kopi.ID, UserID: 20}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("DeleteItemByUser of other user err = %v, want sql.ErrNoRows", err)
+	}
+
+	deleted, err := q.DeleteItemByUser(ctx, models.DeleteItemByUserParams{ID: kopi.ID, UserID: 10})
+	if err != nil || deleted.ID != kopi.ID || deleted.Quantity != kopi.Quantity || !deleted.DeletedAt.Valid {
+		t.Fatalf("DeleteItemByUser = %+v, %v, want %+v soft deleted", deleted, err, kopi)
+	}
+	if _, err := q.DeleteItemByUser(ctx, models.DeleteItemByUserParams{ID: kopi.ID, UserID: 10}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("DeleteItemByUser twice err = %v, want sql.ErrNoRows", err)
+	}
+	if items, err := q.GetItemsByOrderID(ctx, order.ID); err != nil || len(items) != 0 {
+		t.Fatalf("GetItemsByOrderID after delete = %+v, %v", items, err)
+	}
+	if items, err := q.GetUserItems(ctx, models.GetUserItemsParams{UserID: 10, OrderID: order.ID}); err != nil || len(items) != 0 {
+		t.Fatalf("GetUserItems after delete = %+v, %v", items, err)
+	}
+
+	// deleted items do not count towards the same item ordered again
+	again, err := q.AddItemQuantity(ctx, models.AddItemQuantityParams{OrderID: order.ID, UserID: 10, Name: "kopi", Quantity: 1})
+	if err != nil || again.ID == kopi.ID || again.Quantity != 1 {
+		t.Fatalf("AddItemQuantity after delete = %+v, %v, want a new item", again, err)
+	}
+}
+
+func testAPIKeys(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	first, err := q.CreateAPIKey(ctx, models.CreateAPIKeyParams{ChatID: 1, CreatedBy: 10, KeyHash: "first"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if first.RevokedAt.Valid || first.CreatedAt.IsZero() {
+		t.Fatalf("unexpected defaults for new api key: %+v", first)
+	}
+	second, err := q.CreateAPIKey(ctx, models.CreateAPIKeyParams{ChatID: 1, CreatedBy: 10, KeyHash: "second"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if _, err := q.CreateAPIKey(ctx, models.CreateAPIKeyParams{ChatID: 2, CreatedBy: 20, KeyHash: "first"}); err == nil {
+		t.Fatalf("CreateAPIKey with duplicate hash succeeded")
+	}
+
+	apiKey, err := q.GetAPIKeyByHash(ctx, "first")
+	if err != nil || apiKey.ID != first.ID {
+		t.Fatalf("GetAPIKeyByHash = %+v, %v, want %+v", apiKey, err, first)
+	}
+
+	apiKeys, err := q.GetAPIKeysByChatID(ctx, 1)
+	if err != nil || len(apiKeys) != 2 || apiKeys[0].ID != first.ID || apiKeys[1].ID != second.ID {
+		t.Fatalf("GetAPIKeysByChatID = %+v, %v", apiKeys, err)
+	}
+
+	if _, err := q.RevokeAPIKey(ctx, models.RevokeAPIKeyParams{ID: first.ID, ChatID: 2}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("RevokeAPIKey of other chat err = %v, want sql.ErrNoRows", err)
+	}
+	revoked, err := q.RevokeAPIKey(ctx, models.RevokeAPIKeyParams{ID: first.ID, ChatID: 1})
+	if err != nil || !revoked.RevokedAt.Valid {
+		t.Fatalf("RevokeAPIKey = %+v, %v", revoked, err)
+	}
+	if _, err := q.RevokeAPIKey(ctx, models.RevokeAPIKeyParams{ID: first.ID, ChatID: 1}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("RevokeAPIKey twice err = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := q.GetAPIKeyByHash(ctx, "first"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetAPIKeyByHash of revoked key err = %v, want sql.ErrNoRows", err)
+	}
+	if apiKeys, err := q.GetAPIKeysByChatID(ctx, 1); err != nil || len(apiKeys) != 1 || apiKeys[0].ID != second.ID {
+		t.Fatalf("GetAPIKeysByChatID after revoking = %+v, %v", apiKeys, err)
+	}
+}
+
+// supergroup and newer user ids do not fit in 32 bits
+var (
+	supergroupID int64 = -1001234567890
+	largeUserID  int64 = 5000000000
+)
+
+func testTelegramIDs(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	order := mustCreateOrder(t, q, supergroupID, "Coffeeshop")
+	if order.ChatID != supergroupID {
+		t.Fatalf("order chat id = %d, want %d", order.ChatID, supergroupID)
+	}
+	if _, err := q.GetActiveOrder(ctx, int64(int32(supergroupID))); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetActiveOrder of truncated chat id err = %v, want sql.ErrNoRows", err)
+	}
+
+	item := mustCreateItem(t, q, order.ID, largeUserID, "kopi", 1)
+	if item.UserID != largeUserID {
+		t.Fatalf("item user id = %d, want %d", item.UserID, largeUserID)
+	}
+	items, err := q.GetUserItems(ctx, models.GetUserItemsParams{UserID: largeUserID, OrderID: order.ID})
+	if err != nil || len(items) != 1 || items[0] != item {
+		t.Fatalf("GetUserItems = %+v, %v, want %+v", items, err, item)
+	}
+}
+
+func testLargeIDs(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	mustUpsertUser(t, q, 10, "Alice")
+	mustUpsertUser(t, q, largeUserID, "Carol")
+	if err := q.UpsertChat(ctx, models.UpsertChatParams{ID: -1, Type: "group"}); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if err := q.UpsertChat(ctx, models.UpsertChatParams{ID: supergroupID, Type: "supergroup"}); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	// api keys always stored full ids, whether or not the chat has been seen
+	for i, chatID := range []int64{supergroupID, supergroupID - 1} {
+		params := models.CreateAPIKeyParams{ChatID: chatID, CreatedBy: 10, KeyHash: fmt.Sprintf("hash%d", i)}
+		if _, err := q.CreateAPIKey(ctx, params); err != nil {
+			t.Fatalf("CreateAPIKey: %v", err)
+		}
+	}
+
+	chatIDs, err := q.GetLargeChatIDs(ctx)
+	if err != nil || len(chatIDs) != 2 || chatIDs[0] != supergroupID-1 || chatIDs[1] != supergroupID {
+		t.Fatalf("GetLargeChatIDs = %v, %v, want %d and %d", chatIDs, err, supergroupID-1, supergroupID)
+	}
+	userIDs, err := q.GetLargeUserIDs(ctx)
+	if err != nil || len(userIDs) != 1 || userIDs[0] != largeUserID {
+		t.Fatalf("GetLargeUserIDs = %v, %v, want %d", userIDs, err, largeUserID)
+	}
+}
+
+func testRelinkWrappedUserID(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+	wrappedUserID := int64(int32(largeUserID))
+
+	closed := mustCreateOrder(t, q, 1, "Closed")
+	old := mustCreateItem(t, q, closed.ID, wrappedUserID, "kopi", 1)
+	if _, err := q.CancelOrder(ctx, 1); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	kopi := mustCreateItem(t, q, order.ID, wrappedUserID, "kopi", 2)
+	teh := mustCreateItem(t, q, order.ID, wrappedUserID, "teh", 1)
+	other := mustCreateItem(t, q, order.ID, 10, "kopi", 1)
+
+	// items reference users
+	_, err := q.RelinkWrappedUserID(ctx, models.RelinkWrappedUserIDParams{UserID: largeUserID, WrappedUserID: wrappedUserID})
+	if !models.IsForeignKeyViolation(err) {
+		t.Fatalf("RelinkWrappedUserID to missing user err = %v, want foreign key violation", err)
+	}
+
+	// the user already ordered kopi since the ids were fixed
+	merged := mustCreateItem(t, q, order.ID, largeUserID, "KOPI", 1)
+
+	moved, err := q.RelinkWrappedUserID(ctx, models.RelinkWrappedUserIDParams{UserID: largeUserID, WrappedUserID: wrappedUserID})
+	if err != nil || moved != 3 {
+		t.Fatalf("RelinkWrappedUserID = %d, %v, want 3", moved, err)
+	}
+
+	items, err := q.GetItemsByOrderID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetItemsByOrderID: %v", err)
+	}
+	sortItems(items)
+	teh.UserID = largeUserID
+	merged.Quantity += kopi.Quantity
+	if len(items) != 3 || items[0] != teh || items[1] != other || items[2] != merged {
+		t.Fatalf("items after relink = %+v, want %+v, %+v and %+v", items, teh, other, merged)
+	}
+	closedItems, err := q.GetUserItems(ctx, models.GetUserItemsParams{UserID: largeUserID, OrderID: closed.ID})
+	if err != nil || len(closedItems) != 1 || closedItems[0].ID != old.ID {
+		t.Fatalf("GetUserItems of closed order = %+v, %v, want item %d", closedItems, err, old.ID)
+	}
+
+	if moved, err := q.RelinkWrappedUserID(ctx, models.RelinkWrappedUserIDParams{UserID: largeUserID, WrappedUserID: wrappedUserID}); err != nil || moved != 0 {
+		t.Fatalf("RelinkWrappedUserID again = %d, %v, want 0", moved, err)
+	}
+}
+
+func testMigrateChatID(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	closed := mustCreateOrder(t, q, 1, "Closed")
+	if _, err := q.CancelOrder(ctx, 1); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	other := mustCreateOrder(t, q, 2, "Other chat")
+	apiKey, err := q.CreateAPIKey(ctx, models.CreateAPIKeyParams{ChatID: 1, CreatedBy: 10, KeyHash: "hash"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if err := q.SetChatLanguage(ctx, models.SetChatLanguageParams{ID: 1, Language: "zh"}); err != nil {
+		t.Fatalf("SetChatLanguage: %v", err)
+	}
+	// the supergroup is usually seen before the migration
+	if err := q.UpsertChat(ctx, models.UpsertChatParams{ID: supergroupID, Type: "supergroup", Title: "Group"}); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+
+	if err := q.MigrateChatID(ctx, models.MigrateChatIDParams{ChatID: supergroupID, OldChatID: 1}); err != nil {
+		t.Fatalf("MigrateChatID: %v", err)
+	}
+
+	if chat, err := q.GetChat(ctx, supergroupID); err != nil || chat.Language != "zh" || chat.Type != "supergroup" {
+		t.Fatalf("GetChat of new chat = %+v, %v, want language zh", chat, err)
+	}
+
+	active, err := q.GetActiveOrder(ctx, supergroupID)
+	if err != nil || active.ID != order.ID {
+		t.Fatalf("GetActiveOrder of new chat = %+v, %v, want order %d", active, err, order.ID)
+	}
+	if _, err := q.GetActiveOrder(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetActiveOrder of old chat err = %v, want sql.ErrNoRows", err)
+	}
+	if got, err := q.GetOrderByID(ctx, closed.ID); err != nil || got.ChatID != supergroupID {
+		t.Fatalf("closed order after migrate = %+v, %v, want chat id %d", got, err, supergroupID)
+	}
+	if got, err := q.GetOrderByID(ctx, other.ID); err != nil || got != other {
+		t.Fatalf("order of other chat after migrate = %+v, %v, want %+v", got, err, other)
+	}
+	apiKeys, err := q.GetAPIKeysByChatID(ctx, supergroupID)
+	if err != nil || len(apiKeys) != 1 || apiKeys[0].ID != apiKey.ID {
+		t.Fatalf("GetAPIKeysByChatID of new chat = %+v, %v, want key %d", apiKeys, err, apiKey.ID)
+	}
+
+	// an active order already in the new chat is kept, leaving the old one behind, as is the language of the new chat
+	stale := mustCreateOrder(t, q, 3, "Stale")
+	if err := q.SetChatLanguage(ctx, models.SetChatLanguageParams{ID: 3, Language: "ms"}); err != nil {
+		t.Fatalf("SetChatLanguage: %v", err)
+	}
+	if err := q.MigrateChatID(ctx, models.MigrateChatIDParams{ChatID: supergroupID, OldChatID: 3}); err != nil {
+		t.Fatalf("MigrateChatID: %v", err)
+	}
+	if chat, err := q.GetChat(ctx, supergroupID); err != nil || chat.Language != "zh" {
+		t.Fatalf("GetChat of new chat = %+v, %v, want language zh kept", chat, err)
+	}
+	if got, err := q.GetActiveOrder(ctx, 3); err != nil || got.ID != stale.ID {
+		t.Fatalf("GetActiveOrder of old chat = %+v, %v, want order %d kept", got, err, stale.ID)
+	}
+	if got, err := q.GetActiveOrder(ctx, supergroupID); err != nil || got.ID != order.ID {
+		t.Fatalf("GetActiveOrder of new chat = %+v, %v, want order %d", got, err, order.ID)
+	}
+}
+
+func testUsers(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	if _, err := q.GetUser(ctx, largeUserID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetUser of missing user err = %v, want sql.ErrNoRows", err)
+	}
+
+	params := models.UpsertUserParams{ID: largeUserID, FirstName: "Carol", Username: "carol", LanguageCode: "en"}
+	if err := q.UpsertUser(ctx, params); err != nil {
+		t.Fatalf("UpsertUser: %v", err)
+	}
+	created, err := q.GetUser(ctx, largeUserID)
+	if err != nil || created.FirstName != "Carol" || created.Username != "carol" || created.LanguageCode != "en" || created.CreatedAt.IsZero() {
+		t.Fatalf("GetUser = %+v, %v", created, err)
+	}
+
+	if err := q.UpsertUser(ctx, params); err != nil {
+		t.Fatalf("UpsertUser: %v", err)
+	}
+	if unchanged, err := q.GetUser(ctx, largeUserID); err != nil || unchanged != created {
+		t.Fatalf("GetUser after unchanged upsert = %+v, %v, want %+v", unchanged, err, created)
+	}
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	item, err := q.CreateItem(ctx, models.CreateItemParams{OrderID: order.ID, UserID: largeUserID, Name: "kopi", Quantity: 1})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	params.LastName = "Tan"
+	params.Username = ""
+	if err := q.UpsertUser(ctx, params); err != nil {
+		t.Fatalf("UpsertUser: %v", err)
+	}
+	updated, err := q.GetUser(ctx, largeUserID)
+	if err != nil || updated.LastName != "Tan" || updated.Username != "" || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("GetUser after update = %+v, %v", updated, err)
+	}
+
+	items, err := q.GetItemsWithUsersByOrderID(ctx, order.ID)
+	want := models.GetItemsWithUsersByOrderIDRow{
+		ID:        item.ID,
+		UserID:    largeUserID,
+		OrderID:   order.ID,
+		Quantity:  1,
+		Name:      "kopi",
+		FirstName: "Carol",
+		LastName:  "Tan",
+	}
+	if err != nil || len(items) != 1 || items[0] != want {
+		t.Fatalf("GetItemsWithUsersByOrderID = %+v, %v, want %+v", items, err, want)
+	}
+}
+
+func testChats(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	if _, err := q.GetChat(ctx, supergroupID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetChat of missing chat err = %v, want sql.ErrNoRows", err)
+	}
+
+	params := models.UpsertChatParams{ID: supergroupID, Type: "supergroup", Title: "Lunch"}
+	if err := q.UpsertChat(ctx, params); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	params.Title = "Lunch crew"
+	if err := q.UpsertChat(ctx, params); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+
+	chat, err := q.GetChat(ctx, supergroupID)
+	if err != nil || chat.Type != "supergroup" || chat.Title != "Lunch crew" || chat.CreatedAt.IsZero() {
+		t.Fatalf("GetChat = %+v, %v", chat, err)
+	}
+}
+
+func testChatLanguage(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	// set before the chat is seen, such as when its profile failed to save
+	if err := q.SetChatLanguage(ctx, models.SetChatLanguageParams{ID: supergroupID, Language: "zh"}); err != nil {
+		t.Fatalf("SetChatLanguage: %v", err)
+	}
+	if err := q.UpsertChat(ctx, models.UpsertChatParams{ID: supergroupID, Type: "supergroup", Title: "Lunch"}); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+
+	chat, err := q.GetChat(ctx, supergroupID)
+	if err != nil || chat.Language != "zh" || chat.Title != "Lunch" {
+		t.Fatalf("GetChat = %+v, %v, want language kept by UpsertChat", chat, err)
+	}
+
+	if err := q.SetChatLanguage(ctx, models.SetChatLanguageParams{ID: supergroupID}); err != nil {
+		t.Fatalf("SetChatLanguage: %v", err)
+	}
+	chat, err = q.GetChat(ctx, supergroupID)
+	if err != nil || chat.Language != "" {
+		t.Fatalf("GetChat = %+v, %v, want language reset", chat, err)
+	}
+}
+
+func testOneActiveOrderPerChat(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	mustCreateOrder(t, q, 1, "Coffeeshop")
+	_, err := q.CreateOrder(ctx, models.CreateOrderParams{ChatID: 1, Title: "Another"})
+	if !models.IsUniqueViolation(err) {
+		t.Fatalf("CreateOrder with an active order err = %v, want unique violation", err)
+	}
+
+	if _, err := q.CancelOrder(ctx, 1); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	mustCreateOrder(t, q, 1, "Another")
+}
+
+func testAddItemQuantity(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	mustUpsertUser(t, q, 10, "Alice")
+	mustUpsertUser(t, q, 20, "Bob")
+
+	add := func(userID int64, name string, quantity int32) models.Item {
+		t.Helper()
+		item, err := q.AddItemQuantity(ctx, models.AddItemQuantityParams{OrderID: order.ID, UserID: userID, Name: name, Quantity: quantity})
+		if err != nil {
+			t.Fatalf("AddItemQuantity: %v", err)
+		}
+		return item
+	}
+
+	kopi := add(10, "Kopi O", 2)
+	if kopi.Quantity != 2 || kopi.Name != "Kopi O" {
+		t.Fatalf("AddItemQuantity of new item = %+v", kopi)
+	}
+	if added := add(10, "kopi o", 3); added.ID != kopi.ID || added.Quantity != 5 || added.Name != "Kopi O" {
+		t.Fatalf("AddItemQuantity of existing item = %+v, want item %d with quantity 5 and its first name", added, kopi.ID)
+	}
+	if other := add(20, "kopi o", 1); other.ID == kopi.ID || other.Quantity != 1 {
+		t.Fatalf("AddItemQuantity of other user = %+v, want a new item", other)
+	}
+
+	_, err := q.AddItemQuantity(ctx, models.AddItemQuantityParams{OrderID: order.ID, UserID: 30, Name: "kopi", Quantity: 1})
+	if !models.IsForeignKeyViolation(err) {
+		t.Fatalf("AddItemQuantity for missing user err = %v, want foreign key violation", err)
+	}
+}
+
+func testExecTx(t *testing.T, s models.Store) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	err := s.ExecTx(ctx, func(q models.Querier) error {
+		mustCreateOrder(t, q, 1, "Rolled back")
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("ExecTx err = %v, want %v", err, errRollback)
+	}
+	if _, err := s.GetActiveOrder(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetActiveOrder after rollback err = %v, want sql.ErrNoRows", err)
+	}
+
+	var order models.Order
+	err = s.ExecTx(ctx, func(q models.Querier) error {
+		order = mustCreateOrder(t, q, 1, "Committed")
+		return q.UpdateExpiry(ctx, models.UpdateExpiryParams{
+			ID:          order.ID,
+			ExpiryRunAt: sql.NullInt64{Int64: 100, Valid: true},
+			ExpiryID:    sql.NullString{String: "expiry", Valid: true},
+		})
+	})
+	if err != nil {
+		t.Fatalf("ExecTx: %v", err)
+	}
+	active, err := s.GetActiveOrder(ctx, 1)
+	if err != nil || active.ID != order.ID || active.ExpiryID.String != "expiry" {
+		t.Fatalf("GetActiveOrder after commit = %+v, %v, want order %d with expiry job", active, err, order.ID)
+	}
+}
+
+func testOrderEvents(t *testing.T, q models.Querier) {
+	ctx := context.Background()
+	at := time.Date(2021, 3, 26, 12, 30, 0, 0, time.UTC)
+
+	order := mustCreateOrder(t, q, 1, "Coffeeshop")
+	other := mustCreateOrder(t, q, 2, "Other chat")
+	kopi := mustCreateItem(t, q, order.ID, 10, "kopi", 2)
+
+	create := func(params models.CreateOrderEventParams) models.OrderEvent {
+		t.Helper()
+		event, err := q.CreateOrderEvent(ctx, params)
+		if err != nil {
+			t.Fatalf("CreateOrderEvent: %v", err)
+		}
+		return event
+	}
+	created := create(models.CreateOrderEventParams{
+		OrderID:   order.ID,
+		Type:      "create",
+		UserID:    sql.NullInt64{Int64: 10, Valid: true},
+		UpdateID:  sql.NullInt64{Int64: 100, Valid: true},
+		CreatedAt: at,
+	})
+	if !created.CreatedAt.Equal(at) || created.UpdateID.Int64 != 100 {
+		t.Fatalf("CreateOrderEvent = %+v", created)
+	}
+	create(models.CreateOrderEventParams{
+		OrderID:   order.ID,
+		Type:      "add_item",
+		UserID:    sql.NullInt64{Int64: 10, Valid: true},
+		ItemID:    sql.NullInt32{Int32: kopi.ID, Valid: true},
+		ItemName:  "kopi",
+		Quantity:  2,
+		CreatedAt: at.Add(time.Minute),
+	})
+	create(models.CreateOrderEventParams{OrderID: other.ID, Type: "create", CreatedAt: at})
+	create(models.CreateOrderEventParams{OrderID: order.ID, Type: "expire", CreatedAt: at.Add(2 * time.Minute)})
+
+	_, err := q.CreateOrderEvent(ctx, models.CreateOrderEventParams{
+		OrderID:   order.ID,
+		Type:      "create",
+		UserID:    sql.NullInt64{Int64: 30, Valid: true},
+		CreatedAt: at,
+	})
+	if !models.IsForeignKeyViolation(err) {
+		t.Fatalf("CreateOrderEvent for missing user err = %v, want foreign key violation", err)
+	}
+
+	events, err := q.GetRecentOrderEvents(ctx, models.GetRecentOrderEventsParams{OrderID: order.ID, Limit: 2})
+	if err != nil {
+		t.Fatalf("GetRecentOrderEvents: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != "expire" || events[0].FirstName.Valid || events[1].Type != "add_item" {
+		t.Fatalf("GetRecentOrderEvents = %+v, want expire and add_item", events)
+	}
+	if events[1].FirstName.String != "user" || events[1].ItemName != "kopi" || events[1].Quantity != 2 {
+		t.Fatalf("GetRecentOrderEvents add_item = %+v", events[1])
+	}
+}
+
+func mustCreateOrder(t *testing.T, q models.Querier, chatID int64, title string) models.Order {
+	t.Helper()
+	order, err := q.CreateOrder(context.Background(), models.CreateOrderParams{ChatID: chatID, Title: title})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	return order
+}
+
+// mustCreateItem creates the user too, as items reference users
+func mustCreateItem(t *testing.T, q models.Querier, orderID int32, userID int64, name string, quantity int32) models.Item {
+	t.Helper()
+	mustUpsertUser(t, q, userID, "user")
+	item, err := q.CreateItem(context.Background(), models.CreateItemParams{
+		OrderID:  orderID,
+		Quantity: quantity,
+		Name:     name,
+		UserID:   userID,
+	})
+	if err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	return item
+}
+
+func mustUpsertUser(t *testing.T, q models.Querier, id int64, firstName string) {
+	t.Helper()
+	if err := q.UpsertUser(context.Background(), models.UpsertUserParams{ID: id, FirstName: firstName}); err != nil {
+		t.Fatalf("UpsertUser: %v", err)
+	}
+}
+
+func sortItems(items []models.Item) {
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+}
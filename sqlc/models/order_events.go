@@ -0,0 +1,11 @@
+package models
+
+// OrderEvent types, see the order_events table. Orders can't be extended, so there is no extend event.
+const (
+	OrderEventCreate         = "create"
+	OrderEventAddItem        = "add_item"
+	OrderEventChangeQuantity = "change_quantity"
+	OrderEventDeleteItem     = "delete_item"
+	OrderEventClose          = "close"
+	OrderEventExpire         = "expire"
+)
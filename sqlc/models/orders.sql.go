@@ -13,10 +13,10 @@ UPDATE orders
 SET active = FALSE
 WHERE chat_id = $1
 AND active = TRUE
-RETURNING id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id
+RETURNING id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code
 `
 
-func (q *Queries) CancelOrder(ctx context.Context, chatID int32) (Order, error) {
+func (q *Queries) CancelOrder(ctx context.Context, chatID int64) (Order, error) {
 	row := q.queryRow(ctx, q.cancelOrderStmt, cancelOrder, chatID)
 	var i Order
 	err := row.Scan(
@@ -29,24 +29,57 @@ func (q *Queries) CancelOrder(ctx context.Context, chatID int32) (Order, error)
 		&i.ReminderID,
 		&i.ExpiryRunAt,
 		&i.ExpiryID,
+		&i.LanguageCode,
+	)
+	return i, err
+}
+
+const closeOrder = `-- name: CloseOrder :one
+UPDATE orders
+SET active = FALSE
+WHERE id = $1
+AND active = TRUE
+RETURNING id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code
+`
+
+func (q *Queries) CloseOrder(ctx context.Context, id int32) (Order, error) {
+	row := q.queryRow(ctx, q.closeOrderStmt, closeOrder, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.Title,
+		&i.Expiry,
+		&i.Active,
+		&i.ReminderRunAt,
+		&i.ReminderID,
+		&i.ExpiryRunAt,
+		&i.ExpiryID,
+		&i.LanguageCode,
 	)
 	return i, err
 }
 
 const createOrder = `-- name: CreateOrder :one
-INSERT INTO orders (chat_id, title, expiry)
-VALUES ($1, $2, $3)
-RETURNING id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id
+INSERT INTO orders (chat_id, title, expiry, language_code)
+VALUES ($1, $2, $3, $4)
+RETURNING id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code
 `
 
 type CreateOrderParams struct {
-	ChatID int32        `json:"chat_id"`
-	Title  string       `json:"title"`
-	Expiry sql.NullTime `json:"expiry"`
+	ChatID       int64        `json:"chat_id"`
+	Title        string       `json:"title"`
+	Expiry       sql.NullTime `json:"expiry"`
+	LanguageCode string       `json:"language_code"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
-	row := q.queryRow(ctx, q.createOrderStmt, createOrder, arg.ChatID, arg.Title, arg.Expiry)
+	row := q.queryRow(ctx, q.createOrderStmt, createOrder,
+		arg.ChatID,
+		arg.Title,
+		arg.Expiry,
+		arg.LanguageCode,
+	)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -58,6 +91,7 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.ReminderID,
 		&i.ExpiryRunAt,
 		&i.ExpiryID,
+		&i.LanguageCode,
 	)
 	return i, err
 }
@@ -74,12 +108,12 @@ func (q *Queries) DeactivateOrder(ctx context.Context, id int32) error {
 }
 
 const getActiveOrder = `-- name: GetActiveOrder :one
-SELECT id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id FROM orders
+SELECT id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code FROM orders
 WHERE chat_id = $1
 AND active = TRUE
 `
 
-func (q *Queries) GetActiveOrder(ctx context.Context, chatID int32) (Order, error) {
+func (q *Queries) GetActiveOrder(ctx context.Context, chatID int64) (Order, error) {
 	row := q.queryRow(ctx, q.getActiveOrderStmt, getActiveOrder, chatID)
 	var i Order
 	err := row.Scan(
@@ -92,12 +126,38 @@ func (q *Queries) GetActiveOrder(ctx context.Context, chatID int32) (Order, erro
 		&i.ReminderID,
 		&i.ExpiryRunAt,
 		&i.ExpiryID,
+		&i.LanguageCode,
+	)
+	return i, err
+}
+
+const getActiveOrderForUpdate = `-- name: GetActiveOrderForUpdate :one
+SELECT id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code FROM orders
+WHERE chat_id = $1
+AND active = TRUE
+FOR UPDATE
+`
+
+func (q *Queries) GetActiveOrderForUpdate(ctx context.Context, chatID int64) (Order, error) {
+	row := q.queryRow(ctx, q.getActiveOrderForUpdateStmt, getActiveOrderForUpdate, chatID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.Title,
+		&i.Expiry,
+		&i.Active,
+		&i.ReminderRunAt,
+		&i.ReminderID,
+		&i.ExpiryRunAt,
+		&i.ExpiryID,
+		&i.LanguageCode,
 	)
 	return i, err
 }
 
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id FROM orders
+SELECT id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code FROM orders
 WHERE id = $1
 `
 
@@ -114,6 +174,7 @@ func (q *Queries) GetOrderByID(ctx context.Context, id int32) (Order, error) {
 		&i.ReminderID,
 		&i.ExpiryRunAt,
 		&i.ExpiryID,
+		&i.LanguageCode,
 	)
 	return i, err
 }
@@ -151,3 +212,131 @@ func (q *Queries) UpdateReminder(ctx context.Context, arg UpdateReminderParams)
 	_, err := q.exec(ctx, q.updateReminderStmt, updateReminder, arg.ID, arg.ReminderRunAt, arg.ReminderID)
 	return err
 }
+
+const getActiveOrdersWithExpiry = `-- name: GetActiveOrdersWithExpiry :many
+SELECT id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code FROM orders
+WHERE active = TRUE
+AND expiry IS NOT NULL
+ORDER BY id
+`
+
+func (q *Queries) GetActiveOrdersWithExpiry(ctx context.Context) ([]Order, error) {
+	rows, err := q.query(ctx, q.getActiveOrdersWithExpiryStmt, getActiveOrdersWithExpiry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.Title,
+			&i.Expiry,
+			&i.Active,
+			&i.ReminderRunAt,
+			&i.ReminderID,
+			&i.ExpiryRunAt,
+			&i.ExpiryID,
+			&i.LanguageCode,
+			&i.LanguageCode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrdersByChatID = `-- name: GetOrdersByChatID :many
+SELECT id, chat_id, title, expiry, active, reminder_run_at, reminder_id, expiry_run_at, expiry_id, language_code FROM orders
+WHERE chat_id = $1
+ORDER BY id DESC
+`
+
+func (q *Queries) GetOrdersByChatID(ctx context.Context, chatID int64) ([]Order, error) {
+	rows, err := q.query(ctx, q.getOrdersByChatIDStmt, getOrdersByChatID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.Title,
+			&i.Expiry,
+			&i.Active,
+			&i.ReminderRunAt,
+			&i.ReminderID,
+			&i.ExpiryRunAt,
+			&i.ExpiryID,
+			&i.LanguageCode,
+			&i.LanguageCode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const migrateChatID = `-- name: MigrateChatID :exec
+WITH copied_chat AS (
+  INSERT INTO chats (id, type, title, username, language)
+  SELECT $1, c.type, c.title, c.username, c.language FROM chats c
+  WHERE c.id = $2
+  ON CONFLICT (id) DO UPDATE
+  SET language = EXCLUDED.language,
+    updated_at = NOW()
+  WHERE chats.language = ''
+), moved_orders AS (
+  UPDATE orders
+  SET chat_id = $1
+  WHERE orders.chat_id = $2
+  AND (
+    orders.active = FALSE
+    OR NOT EXISTS (SELECT 1 FROM orders o WHERE o.chat_id = $1 AND o.active = TRUE)
+  )
+)
+UPDATE api_keys
+SET chat_id = $1
+WHERE api_keys.chat_id = $2
+`
+
+type MigrateChatIDParams struct {
+	ChatID    int64 `json:"chat_id"`
+	OldChatID int64 `json:"old_chat_id"`
+}
+
+func (q *Queries) MigrateChatID(ctx context.Context, arg MigrateChatIDParams) error {
+	_, err := q.exec(ctx, q.migrateChatIDStmt, migrateChatID, arg.ChatID, arg.OldChatID)
+	return err
+}
+
+const countActiveOrders = `-- name: CountActiveOrders :one
+SELECT COUNT(*) FROM orders
+WHERE active = TRUE
+`
+
+func (q *Queries) CountActiveOrders(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countActiveOrdersStmt, countActiveOrders)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
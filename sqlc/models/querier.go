@@ -7,19 +7,41 @@ import (
 )
 
 type Querier interface {
-	CancelOrder(ctx context.Context, chatID int32) (Order, error)
+	AddItemQuantity(ctx context.Context, arg AddItemQuantityParams) (Item, error)
+	CancelOrder(ctx context.Context, chatID int64) (Order, error)
+	CloseOrder(ctx context.Context, id int32) (Order, error)
+	CountActiveOrders(ctx context.Context) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateItem(ctx context.Context, arg CreateItemParams) (Item, error)
 	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	CreateOrderEvent(ctx context.Context, arg CreateOrderEventParams) (OrderEvent, error)
 	DeactivateOrder(ctx context.Context, id int32) error
 	DeleteItemByUser(ctx context.Context, arg DeleteItemByUserParams) (Item, error)
-	GetActiveOrder(ctx context.Context, chatID int32) (Order, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetAPIKeysByChatID(ctx context.Context, chatID int64) ([]ApiKey, error)
+	GetActiveOrder(ctx context.Context, chatID int64) (Order, error)
+	GetActiveOrderForUpdate(ctx context.Context, chatID int64) (Order, error)
+	GetActiveOrdersWithExpiry(ctx context.Context) ([]Order, error)
+	GetChat(ctx context.Context, id int64) (ChatProfile, error)
 	GetItem(ctx context.Context, arg GetItemParams) (Item, error)
 	GetItemsByOrderID(ctx context.Context, orderID int32) ([]Item, error)
+	GetItemsWithUsersByOrderID(ctx context.Context, orderID int32) ([]GetItemsWithUsersByOrderIDRow, error)
+	GetLargeChatIDs(ctx context.Context) ([]int64, error)
+	GetLargeUserIDs(ctx context.Context) ([]int64, error)
 	GetOrderByID(ctx context.Context, id int32) (Order, error)
+	GetOrdersByChatID(ctx context.Context, chatID int64) ([]Order, error)
+	GetRecentOrderEvents(ctx context.Context, arg GetRecentOrderEventsParams) ([]GetRecentOrderEventsRow, error)
+	GetUser(ctx context.Context, id int64) (UserProfile, error)
 	GetUserItems(ctx context.Context, arg GetUserItemsParams) ([]Item, error)
+	MigrateChatID(ctx context.Context, arg MigrateChatIDParams) error
+	RelinkWrappedUserID(ctx context.Context, arg RelinkWrappedUserIDParams) (int64, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (ApiKey, error)
+	SetChatLanguage(ctx context.Context, arg SetChatLanguageParams) error
 	UpdateExpiry(ctx context.Context, arg UpdateExpiryParams) error
 	UpdateItemQuantity(ctx context.Context, arg UpdateItemQuantityParams) (Item, error)
 	UpdateReminder(ctx context.Context, arg UpdateReminderParams) error
+	UpsertChat(ctx context.Context, arg UpsertChatParams) error
+	UpsertUser(ctx context.Context, arg UpsertUserParams) error
 }
 
 var _ Querier = (*Queries)(nil)
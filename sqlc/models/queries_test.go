@@ -0,0 +1,15 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/gpng/order-bot/services/postgres/postgrestest"
+	"github.com/gpng/order-bot/sqlc/models"
+	"github.com/gpng/order-bot/sqlc/models/modelstest"
+)
+
+func TestQuerier(t *testing.T) {
+	modelstest.TestQuerier(t, func(t *testing.T) models.Store {
+		return models.NewStore(postgrestest.New(t))
+	})
+}
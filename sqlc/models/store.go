@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Store runs queries, and units of work that must be applied together
+type Store interface {
+	Querier
+	// ExecTx runs fn in a transaction, which is committed if fn returns nil and rolled back otherwise
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// SQLStore is a Store backed by postgres
+type SQLStore struct {
+	*Queries
+	db *sql.DB
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// NewStore with queries run on db, each in a span
+func NewStore(db *sql.DB) *SQLStore {
+	return &SQLStore{Queries: New(tracedDB{db}), db: db}
+}
+
+// ExecTx runs fn with queries bound to a new transaction
+func (s *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(New(tracedDB{tx})); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w, rollback failed: %v", err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// postgres error codes
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// IsUniqueViolation returns true if err was caused by a unique constraint
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// IsForeignKeyViolation returns true if err was caused by a foreign key constraint
+func IsForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation
+}
@@ -2,24 +2,32 @@ package models
 
 // Chat model
 type Chat struct {
-	ID int64 `json:"id"`
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Username string `json:"username"`
 }
 
 // User model
 type User struct {
-	ID        int64  `json:"id"`
-	IsBot     bool   `json:"is_bot"`
-	FirstName string `json:"first_name"`
+	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
 }
 
 // Message model
 type Message struct {
-	MessageID        int    `json:"message_id"`
-	Chat             Chat   `json:"chat"`
-	Text             string `json:"text"`
-	From             User   `json:"from"`
-	GroupChatCreated bool   `json:"group_chat_created"`
-	NewChatMembers   []User `json:"new_chat_members"`
+	MessageID         int    `json:"message_id"`
+	Chat              Chat   `json:"chat"`
+	Text              string `json:"text"`
+	From              User   `json:"from"`
+	GroupChatCreated  bool   `json:"group_chat_created"`
+	NewChatMembers    []User `json:"new_chat_members"`
+	MigrateToChatID   int64  `json:"migrate_to_chat_id"`
+	MigrateFromChatID int64  `json:"migrate_from_chat_id"`
 }
 
 // TelegramUpdate model
@@ -0,0 +1,23 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (chat_id, created_by, key_hash)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetAPIKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1
+AND revoked_at IS NULL;
+
+-- name: GetAPIKeysByChatID :many
+SELECT * FROM api_keys
+WHERE chat_id = $1
+AND revoked_at IS NULL
+ORDER BY id;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1
+AND chat_id = $2
+AND revoked_at IS NULL
+RETURNING *;
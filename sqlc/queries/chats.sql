@@ -0,0 +1,29 @@
+-- name: UpsertChat :exec
+INSERT INTO chats (id, type, title, username)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE
+SET type = EXCLUDED.type,
+  title = EXCLUDED.title,
+  username = EXCLUDED.username,
+  updated_at = NOW()
+WHERE (chats.type, chats.title, chats.username)
+  IS DISTINCT FROM (EXCLUDED.type, EXCLUDED.title, EXCLUDED.username);
+
+-- name: GetChat :one
+SELECT * FROM chats
+WHERE id = $1;
+
+-- name: SetChatLanguage :exec
+INSERT INTO chats (id, language)
+VALUES ($1, $2)
+ON CONFLICT (id) DO UPDATE
+SET language = EXCLUDED.language,
+  updated_at = NOW();
+
+-- name: GetLargeChatIDs :many
+SELECT id FROM chats
+WHERE id NOT BETWEEN -2147483648 AND 2147483647
+UNION
+SELECT chat_id FROM api_keys
+WHERE chat_id NOT BETWEEN -2147483648 AND 2147483647
+ORDER BY id;
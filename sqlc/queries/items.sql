@@ -1,17 +1,19 @@
 -- name: CreateItem :one
-INSERT INTO items (order_id, quantity, name, user_id, user_name)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO items (order_id, quantity, name, user_id)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetItemsByOrderID :many
 SELECT * FROM items
-WHERE order_id = $1;
+WHERE order_id = $1
+AND deleted_at IS NULL;
 
 -- name: GetItem :one
 SELECT * FROM items
 WHERE order_id = $1
 AND user_id = $2
-AND LOWER(name) = LOWER($3);
+AND LOWER(name) = LOWER($3)
+AND deleted_at IS NULL;
 
 -- name: UpdateItemQuantity :one
 UPDATE items
@@ -19,13 +21,51 @@ SET quantity = $4
 WHERE order_id = $1
 AND user_id = $2
 AND LOWER(name) = LOWER($3)
+AND deleted_at IS NULL
 RETURNING *;
 
 -- name: GetUserItems :many
 SELECT * FROM items
-WHERE user_id = $1 AND order_id = $2;
+WHERE user_id = $1 AND order_id = $2
+AND deleted_at IS NULL;
 
 -- name: DeleteItemByUser :one
-DELETE FROM items
+UPDATE items
+SET deleted_at = NOW()
 WHERE id = $1 AND user_id = $2
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RelinkWrappedUserID :execrows
+WITH merged AS (
+  UPDATE items
+  SET quantity = items.quantity + wrapped.quantity
+  FROM items wrapped
+  WHERE wrapped.user_id = sqlc.arg(wrapped_user_id)
+  AND wrapped.deleted_at IS NULL
+  AND items.user_id = sqlc.arg(user_id)
+  AND items.order_id = wrapped.order_id
+  AND LOWER(items.name) = LOWER(wrapped.name)
+  AND items.deleted_at IS NULL
+  RETURNING wrapped.id
+)
+UPDATE items
+SET user_id = sqlc.arg(user_id),
+  deleted_at = CASE WHEN items.id IN (SELECT id FROM merged) THEN NOW() ELSE items.deleted_at END
+WHERE items.user_id = sqlc.arg(wrapped_user_id);
+
+-- name: GetItemsWithUsersByOrderID :many
+SELECT items.id, items.user_id, items.order_id, items.quantity, items.name,
+  users.first_name, users.last_name, users.username
+FROM items
+JOIN users ON users.id = items.user_id
+WHERE items.order_id = $1
+AND items.deleted_at IS NULL
+ORDER BY items.id;
+
+-- name: AddItemQuantity :one
+INSERT INTO items (order_id, user_id, name, quantity)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (order_id, user_id, (LOWER(name))) WHERE deleted_at IS NULL DO UPDATE
+SET quantity = items.quantity + EXCLUDED.quantity
 RETURNING *;
@@ -0,0 +1,14 @@
+-- name: CreateOrderEvent :one
+INSERT INTO order_events (order_id, type, user_id, item_id, item_name, quantity, update_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetRecentOrderEvents :many
+SELECT order_events.id, order_events.type, order_events.user_id, order_events.item_name,
+  order_events.quantity, order_events.created_at,
+  users.first_name, users.last_name, users.username
+FROM order_events
+LEFT JOIN users ON users.id = order_events.user_id
+WHERE order_events.order_id = $1
+ORDER BY order_events.id DESC
+LIMIT $2;
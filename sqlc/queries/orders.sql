@@ -1,6 +1,6 @@
 -- name: CreateOrder :one
-INSERT INTO orders (chat_id, title, expiry)
-VALUES ($1, $2, $3)
+INSERT INTO orders (chat_id, title, expiry, language_code)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetActiveOrder :one
@@ -8,6 +8,12 @@ SELECT * FROM orders
 WHERE chat_id = $1
 AND active = TRUE;
 
+-- name: GetActiveOrderForUpdate :one
+SELECT * FROM orders
+WHERE chat_id = $1
+AND active = TRUE
+FOR UPDATE;
+
 -- name: CancelOrder :one
 UPDATE orders
 SET active = FALSE
@@ -15,6 +21,13 @@ WHERE chat_id = $1
 AND active = TRUE
 RETURNING *;
 
+-- name: CloseOrder :one
+UPDATE orders
+SET active = FALSE
+WHERE id = $1
+AND active = TRUE
+RETURNING *;
+
 -- name: GetOrderByID :one
 SELECT * FROM orders
 WHERE id = $1;
@@ -33,3 +46,40 @@ WHERE id = $1;
 UPDATE orders
 SET expiry_run_at = $2, expiry_id = $3
 WHERE id = $1;
+
+-- name: GetActiveOrdersWithExpiry :many
+SELECT * FROM orders
+WHERE active = TRUE
+AND expiry IS NOT NULL
+ORDER BY id;
+
+-- name: GetOrdersByChatID :many
+SELECT * FROM orders
+WHERE chat_id = $1
+ORDER BY id DESC;
+
+-- name: MigrateChatID :exec
+WITH copied_chat AS (
+  INSERT INTO chats (id, type, title, username, language)
+  SELECT sqlc.arg(chat_id), c.type, c.title, c.username, c.language FROM chats c
+  WHERE c.id = sqlc.arg(old_chat_id)
+  ON CONFLICT (id) DO UPDATE
+  SET language = EXCLUDED.language,
+    updated_at = NOW()
+  WHERE chats.language = ''
+), moved_orders AS (
+  UPDATE orders
+  SET chat_id = sqlc.arg(chat_id)
+  WHERE orders.chat_id = sqlc.arg(old_chat_id)
+  AND (
+    orders.active = FALSE
+    OR NOT EXISTS (SELECT 1 FROM orders o WHERE o.chat_id = sqlc.arg(chat_id) AND o.active = TRUE)
+  )
+)
+UPDATE api_keys
+SET chat_id = sqlc.arg(chat_id)
+WHERE api_keys.chat_id = sqlc.arg(old_chat_id);
+
+-- name: CountActiveOrders :one
+SELECT COUNT(*) FROM orders
+WHERE active = TRUE;
@@ -0,0 +1,21 @@
+-- name: UpsertUser :exec
+INSERT INTO users (id, is_bot, first_name, last_name, username, language_code)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id) DO UPDATE
+SET is_bot = EXCLUDED.is_bot,
+  first_name = EXCLUDED.first_name,
+  last_name = EXCLUDED.last_name,
+  username = EXCLUDED.username,
+  language_code = EXCLUDED.language_code,
+  updated_at = NOW()
+WHERE (users.is_bot, users.first_name, users.last_name, users.username, users.language_code)
+  IS DISTINCT FROM (EXCLUDED.is_bot, EXCLUDED.first_name, EXCLUDED.last_name, EXCLUDED.username, EXCLUDED.language_code);
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetLargeUserIDs :many
+SELECT id FROM users
+WHERE id NOT BETWEEN -2147483648 AND 2147483647
+ORDER BY id;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- NOW() is the wall clock in the session time zone, which the app sets to TIMEZONE like every TIMESTAMP column
+CREATE TABLE api_keys (
+  id SERIAL PRIMARY KEY,
+  chat_id BIGINT NOT NULL,
+  created_by BIGINT NOT NULL,
+  key_hash TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  revoked_at TIMESTAMP
+);
+
+CREATE INDEX api_keys_chat_id_idx ON api_keys (chat_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS api_keys;
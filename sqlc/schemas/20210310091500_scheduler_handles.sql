@@ -0,0 +1,12 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- job ids are now scheduler handles, which for gocraft/work are "<run at>:<job id>"
+UPDATE orders SET reminder_id = reminder_run_at || ':' || reminder_id
+WHERE reminder_id IS NOT NULL AND reminder_run_at IS NOT NULL AND reminder_id NOT LIKE '%:%';
+UPDATE orders SET expiry_id = expiry_run_at || ':' || expiry_id
+WHERE expiry_id IS NOT NULL AND expiry_run_at IS NOT NULL AND expiry_id NOT LIKE '%:%';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+UPDATE orders SET reminder_id = split_part(reminder_id, ':', 2) WHERE reminder_id LIKE '%:%';
+UPDATE orders SET expiry_id = split_part(expiry_id, ':', 2) WHERE expiry_id LIKE '%:%';
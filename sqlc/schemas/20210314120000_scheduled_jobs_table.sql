@@ -0,0 +1,29 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- times are unix seconds, like gocraft/work and orders.expiry_run_at
+CREATE TABLE scheduled_jobs (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  args JSONB NOT NULL DEFAULT '{}',
+  run_at BIGINT NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  locked_until BIGINT,
+  failed_at BIGINT,
+  last_error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX scheduled_jobs_run_at_idx ON scheduled_jobs (run_at) WHERE failed_at IS NULL;
+CREATE UNIQUE INDEX scheduled_jobs_unique_idx ON scheduled_jobs (name, args) WHERE failed_at IS NULL;
+
+CREATE TABLE processed_updates (
+  update_id BIGINT PRIMARY KEY,
+  processed_at BIGINT NOT NULL
+);
+
+CREATE INDEX processed_updates_processed_at_idx ON processed_updates (processed_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS processed_updates;
+DROP TABLE IF EXISTS scheduled_jobs;
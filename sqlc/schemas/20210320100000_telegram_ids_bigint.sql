@@ -0,0 +1,14 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- telegram chat and user ids need 52 bits, supergroup ids such as -1001234567890 were stored truncated to 32 bits
+ALTER TABLE orders ALTER COLUMN chat_id TYPE BIGINT;
+ALTER TABLE items ALTER COLUMN user_id TYPE BIGINT;
+
+-- the full ids of truncated ones are only known once their chats and users are seen again, so operators
+-- must run api migrate relink-ids after upgrading, and again later for chats and users seen since
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+-- fails if any id no longer fits, rather than truncating it again
+ALTER TABLE items ALTER COLUMN user_id TYPE INT;
+ALTER TABLE orders ALTER COLUMN chat_id TYPE INT;
@@ -0,0 +1,41 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE users (
+  id BIGINT PRIMARY KEY,
+  is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+  first_name TEXT NOT NULL,
+  last_name TEXT NOT NULL DEFAULT '',
+  username TEXT NOT NULL DEFAULT '',
+  language_code TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE chats (
+  id BIGINT PRIMARY KEY,
+  type TEXT NOT NULL DEFAULT '',
+  title TEXT NOT NULL DEFAULT '',
+  username TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- item user names were snapshots of the first name, the latest one is kept until the user is seen again
+INSERT INTO users (id, first_name)
+SELECT DISTINCT ON (user_id) user_id, user_name FROM items
+ORDER BY user_id, id DESC;
+
+INSERT INTO chats (id)
+SELECT DISTINCT chat_id FROM orders;
+
+ALTER TABLE items ADD CONSTRAINT items_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+ALTER TABLE items DROP COLUMN user_name;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE items ADD COLUMN user_name TEXT NOT NULL DEFAULT '';
+UPDATE items SET user_name = users.first_name FROM users WHERE users.id = items.user_id;
+ALTER TABLE items ALTER COLUMN user_name DROP DEFAULT;
+ALTER TABLE items DROP CONSTRAINT items_user_id_fkey;
+DROP TABLE IF EXISTS chats;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- concurrent /takeorders could create several active orders in a chat, only the newest one is kept
+UPDATE orders SET active = FALSE
+WHERE active = TRUE
+AND id NOT IN (SELECT MAX(id) FROM orders WHERE active = TRUE GROUP BY chat_id);
+
+CREATE UNIQUE INDEX orders_chat_id_active_idx ON orders (chat_id) WHERE active = TRUE;
+
+-- concurrent /order could create duplicate items, which are merged into the first
+UPDATE items SET quantity = merged.quantity
+FROM (
+  SELECT MIN(id) AS id, SUM(quantity) AS quantity FROM items
+  GROUP BY order_id, user_id, LOWER(name)
+  HAVING COUNT(*) > 1
+) merged
+WHERE items.id = merged.id;
+
+DELETE FROM items
+WHERE id NOT IN (SELECT MIN(id) FROM items GROUP BY order_id, user_id, LOWER(name));
+
+CREATE UNIQUE INDEX items_order_id_user_id_name_idx ON items (order_id, user_id, LOWER(name));
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS items_order_id_user_id_name_idx;
+DROP INDEX IF EXISTS orders_chat_id_active_idx;
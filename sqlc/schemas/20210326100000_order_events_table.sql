@@ -0,0 +1,44 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- items are soft deleted, so that the order log can refer to them
+ALTER TABLE items ADD COLUMN deleted_at TIMESTAMP;
+
+DROP INDEX items_order_id_user_id_name_idx;
+CREATE UNIQUE INDEX items_order_id_user_id_name_idx ON items (order_id, user_id, LOWER(name)) WHERE deleted_at IS NULL;
+
+CREATE TABLE order_events (
+  id BIGSERIAL PRIMARY KEY,
+  order_id INT NOT NULL REFERENCES orders(id),
+  type TEXT NOT NULL,
+  -- null for events by the bot, such as expiry
+  user_id BIGINT REFERENCES users(id),
+  item_id INT REFERENCES items(id),
+  item_name TEXT NOT NULL DEFAULT '',
+  quantity INT NOT NULL DEFAULT 0,
+  update_id BIGINT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX order_events_order_id_idx ON order_events (order_id, id);
+
+-- +goose StatementBegin
+CREATE FUNCTION order_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'order_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER order_events_append_only
+BEFORE UPDATE OR DELETE ON order_events
+FOR EACH ROW EXECUTE PROCEDURE order_events_append_only();
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE IF EXISTS order_events;
+DROP FUNCTION IF EXISTS order_events_append_only();
+
+DELETE FROM items WHERE deleted_at IS NOT NULL;
+DROP INDEX items_order_id_user_id_name_idx;
+CREATE UNIQUE INDEX items_order_id_user_id_name_idx ON items (order_id, user_id, LOWER(name));
+ALTER TABLE items DROP COLUMN deleted_at;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- empty until set with /language, messages are then in the language of the user
+ALTER TABLE chats ADD COLUMN language TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE chats DROP COLUMN language;
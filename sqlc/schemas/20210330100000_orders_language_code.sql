@@ -0,0 +1,8 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- telegram language of the user who took orders, for messages sent by jobs to chats without a language
+ALTER TABLE orders ADD COLUMN language_code TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE orders DROP COLUMN language_code;
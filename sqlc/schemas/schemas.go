@@ -0,0 +1,9 @@
+// Package schemas embeds the goose migrations in this directory
+package schemas
+
+import "embed"
+
+// FS contains the migrations
+//
+//go:embed *.sql
+var FS embed.FS